@@ -2,6 +2,7 @@ package ink
 
 import (
 	"fmt"
+	"slices"
 )
 
 // CallStackElement represents a single frame in the call stack.
@@ -12,6 +13,10 @@ type CallStackElement struct {
 	Type                            PushPopType
 	EvaluationStackHeightWhenPushed int
 	FunctionStartInOutputStream     int
+
+	// temporaryVariableNames lists TemporaryVariables in the order they were
+	// declared, which is the order saves list them in.
+	temporaryVariableNames []string
 }
 
 // NewCallStackElement creates a new CallStackElement.
@@ -34,6 +39,7 @@ func (e *CallStackElement) Copy() *CallStackElement {
 		Type:                            e.Type,
 		EvaluationStackHeightWhenPushed: e.EvaluationStackHeightWhenPushed,
 		FunctionStartInOutputStream:     e.FunctionStartInOutputStream,
+		temporaryVariableNames:          slices.Clone(e.temporaryVariableNames),
 	}
 	for k, v := range e.TemporaryVariables {
 		cp.TemporaryVariables[k] = v
@@ -41,6 +47,15 @@ func (e *CallStackElement) Copy() *CallStackElement {
 	return cp
 }
 
+// setTemporaryVariable sets a temporary variable, remembering the order
+// temporaries are declared in.
+func (e *CallStackElement) setTemporaryVariable(name string, value RuntimeObject) {
+	if _, ok := e.TemporaryVariables[name]; !ok {
+		e.temporaryVariableNames = append(e.temporaryVariableNames, name)
+	}
+	e.TemporaryVariables[name] = value
+}
+
 // CallStackThread represents a thread of execution in the story.
 type CallStackThread struct {
 	CallStack       []*CallStackElement
@@ -117,6 +132,21 @@ func (cs *CallStack) PushThread() {
 	cs.Threads = append(cs.Threads, newThread)
 }
 
+// ForkThread returns a copy of the current thread with a new thread index,
+// without pushing it onto the thread stack.
+func (cs *CallStack) ForkThread() *CallStackThread {
+	forkedThread := cs.CurrentThread().Copy()
+	cs.ThreadCounter++
+	forkedThread.ThreadIndex = cs.ThreadCounter
+	return forkedThread
+}
+
+// SetCurrentThread replaces the thread stack with the given thread. This is
+// used when choosing a choice, at which point all threads have finished.
+func (cs *CallStack) SetCurrentThread(thread *CallStackThread) {
+	cs.Threads = []*CallStackThread{thread}
+}
+
 // CurrentElement returns the current element (top of stack) of the current thread.
 func (cs *CallStack) CurrentElement() *CallStackElement {
 	thread := cs.CurrentThread()
@@ -138,6 +168,9 @@ func (cs *CallStack) GetTemporaryVariableWithName(name string, contextIndex int)
 	}
 
 	thread := cs.CurrentThread()
+	if contextIndex < 1 || contextIndex > len(thread.CallStack) {
+		return nil
+	}
 	contextElement := thread.CallStack[contextIndex-1]
 
 	if val, ok := contextElement.TemporaryVariables[name]; ok {
@@ -161,7 +194,11 @@ func (cs *CallStack) SetTemporaryVariable(name string, value RuntimeObject, decl
 		}
 	}
 
-	contextElement.TemporaryVariables[name] = value
+	if oldValue, ok := contextElement.TemporaryVariables[name]; ok {
+		retainListOriginsForAssignment(oldValue, value)
+	}
+
+	contextElement.setTemporaryVariable(name, value)
 	return nil
 }
 
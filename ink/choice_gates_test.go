@@ -81,4 +81,24 @@ func TestChoiceLogicGates(t *testing.T) {
 			t.Errorf("Pointer advance logic failed? Got '%s' instead of 'Content'", res)
 		}
 	})
+
+	// Gate 4: CHOICE_COUNT() sees the choices generated so far
+	t.Run("ChoiceCount", func(t *testing.T) {
+		// * [one]
+		// * [two]
+		// {CHOICE_COUNT()}
+		jsonStr := `{"root": [["ev", "str", "^one", "/str", "/ev", {"*": ".^.c-0", "flg": 20}, "ev", "str", "^two", "/str", "/ev", {"*": ".^.c-1", "flg": 20}, "ev", "choiceCnt", "out", "/ev", "\n", "done", {"c-0": ["done", {"#f": 5}], "c-1": ["done", {"#f": 5}]}]], "inkVersion": 21}`
+		s, err := NewStory(jsonStr)
+		if err != nil {
+			t.Fatalf("Failed to load story: %v", err)
+		}
+
+		text, err := s.ContinueMaximally()
+		if err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		if text != "2\n" {
+			t.Errorf("Expected CHOICE_COUNT() to be %q, got %q", "2\n", text)
+		}
+	})
 }
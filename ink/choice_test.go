@@ -44,7 +44,8 @@ func TestChoiceInteraction(t *testing.T) {
 	if err != nil {
 		t.Fatalf("First continue failed: %v", err)
 	}
-	expectedText := "Start.\n\n"
+	// The second newline directly follows the first, so it isn't output
+	expectedText := "Start.\n"
 	if text != expectedText {
 		t.Errorf("Expected '%s', got '%s'", expectedText, text)
 	}
@@ -107,9 +108,9 @@ func TestChoiceInteraction(t *testing.T) {
 		t.Fatalf("Second continue failed: %v", err)
 	}
 
-	// Output expectation: \nYou chose A.\n based on c-0 content.
 	// c-0: ["\n", "^You chose A.", "\n", "done", ...]
-	expectedText2 := "\nYou chose A.\n"
+	// A newline at the very start of the output is dropped.
+	expectedText2 := "You chose A.\n"
 	if text != expectedText2 {
 		t.Errorf("Expected '%s', got '%s'", expectedText2, text)
 	}
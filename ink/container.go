@@ -94,6 +94,12 @@ func (c *Container) ContentAtPathComponent(component Component) (RuntimeObject,
 		}
 		return nil, fmt.Errorf("index out of bounds: %d", component.Index)
 	}
+	if component.IsParent() {
+		if c.parent == nil {
+			return nil, fmt.Errorf("container has no parent")
+		}
+		return c.parent, nil
+	}
 	if found, ok := c.NamedContent[component.Name]; ok {
 		return found, nil
 	}
@@ -102,6 +108,29 @@ func (c *Container) ContentAtPathComponent(component Component) (RuntimeObject,
 	return nil, fmt.Errorf("content not found for name: %s", component.Name)
 }
 
+// ContentAtPath returns the content at the given path, relative to this
+// container, or nil if the path can't be resolved exactly.
+func (c *Container) ContentAtPath(path *Path) RuntimeObject {
+	var currentObj RuntimeObject = c
+	currentContainer := c
+
+	for _, component := range path.Components {
+		if currentContainer == nil {
+			return nil
+		}
+
+		found, err := currentContainer.ContentAtPathComponent(component)
+		if err != nil {
+			return nil
+		}
+
+		currentObj = found
+		currentContainer, _ = found.(*Container)
+	}
+
+	return currentObj
+}
+
 // GetPath gets the path of this object in the story hierarchy.
 // Overridden to provide correct type information to parent.
 //
@@ -43,3 +43,7 @@ func (d *DivertTargetValue) Cast(newType ValueType) (Value, error) {
 func (d *DivertTargetValue) GetValueObject() any {
 	return d.TargetPath
 }
+
+func (d *DivertTargetValue) String() string {
+	return fmt.Sprintf("DivertTargetValue(%s)", d.TargetPath)
+}
@@ -0,0 +1,69 @@
+package ink
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EvaluateFunction runs the ink function with the given name to completion
+// and returns its return value along with any text it output. The state of
+// the main story, including its output, is left as it was, so this can be
+// used to query the story without moving the narrative on.
+//
+// Arguments and the result are converted with NativeToRuntimeObject and
+// RuntimeObjectToNative. A function without a return value gives nil.
+func (s *Story) EvaluateFunction(functionName string, args ...any) (any, string, error) {
+	if strings.TrimSpace(functionName) == "" {
+		return nil, "", fmt.Errorf("function is empty or white space")
+	}
+
+	funcContainer, ok := s.MainContent.NamedContent[functionName].(*Container)
+	if !ok {
+		return nil, "", fmt.Errorf("function doesn't exist: '%s'", functionName)
+	}
+
+	// Snapshot the output stream, and restore it however the evaluation
+	// ends, in case this was called during main story evaluation
+	outputStreamBefore := s.state.GetOutputStream()
+	errorCountBefore := len(s.state.GetCurrentErrors())
+	s.state.ResetOutput()
+	defer func() {
+		s.state.CurrentFlow.OutputStream = outputStreamBefore
+		s.state.markOutputStreamDirty()
+	}()
+
+	if err := s.state.StartFunctionEvaluationFromGame(funcContainer, args); err != nil {
+		return nil, "", err
+	}
+
+	// Evaluate the function, and collect the string output
+	var sb strings.Builder
+	for s.canContinueInternal() {
+		text, err := s.Continue()
+		if err != nil {
+			s.state.abortFunctionEvaluationFromGame()
+			return nil, sb.String(), err
+		}
+		sb.WriteString(text)
+	}
+	textOutput := sb.String()
+
+	returnedObj, err := s.state.CompleteFunctionEvaluationFromGame()
+	if err != nil {
+		// The function stopped part way through, e.g. at a DONE
+		s.state.abortFunctionEvaluationFromGame()
+		return nil, textOutput, err
+	}
+	if newErrors := s.state.GetCurrentErrors()[errorCountBefore:]; len(newErrors) > 0 {
+		return nil, textOutput, fmt.Errorf("error evaluating function '%s': %s", functionName, strings.Join(newErrors, "; "))
+	}
+	if returnedObj == nil {
+		return nil, textOutput, nil
+	}
+
+	result, err := RuntimeObjectToNative(returnedObj)
+	if err != nil {
+		return nil, textOutput, err
+	}
+	return result, textOutput, nil
+}
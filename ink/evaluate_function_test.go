@@ -0,0 +1,98 @@
+package ink
+
+import "testing"
+
+func TestEvaluateFunction(t *testing.T) {
+	// Hello
+	// === function multiply(a, b) ===
+	// In function. {a + b}
+	// ~ return a * b
+	jsonStr := `{"root": [["^Hello", "\n", "done"], "done", {"multiply": [
+		{"temp=": "b"}, {"temp=": "a"},
+		"^In function. ", "ev", {"VAR?": "a"}, {"VAR?": "b"}, "+", "out", "/ev", "\n",
+		"ev", {"VAR?": "a"}, {"VAR?": "b"}, "*", "/ev", "~ret", null]}], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	if _, err := story.Continue(); err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+
+	result, output, err := story.EvaluateFunction("multiply", 3, 4)
+	if err != nil {
+		t.Fatalf("EvaluateFunction failed: %v", err)
+	}
+	if result != 12 {
+		t.Errorf("Expected result 12, got %v", result)
+	}
+	if output != "In function. 7\n" {
+		t.Errorf("Expected output %q, got %q", "In function. 7\n", output)
+	}
+
+	// The main story is untouched
+	if story.CurrentText() != "Hello\n" {
+		t.Errorf("Expected current text to be restored, got %q", story.CurrentText())
+	}
+	if len(story.State().EvaluationStack) != 0 {
+		t.Errorf("Expected an empty evaluation stack, got %v", story.State().EvaluationStack)
+	}
+	if story.State().GetCallStack().GetDepth() != 1 {
+		t.Errorf("Expected the function frame to be popped")
+	}
+
+	if _, _, err := story.EvaluateFunction("missing"); err == nil {
+		t.Errorf("Expected an error for a missing function")
+	}
+	if _, _, err := story.EvaluateFunction("multiply", 3, struct{}{}); err == nil {
+		t.Errorf("Expected an error for an unsupported argument")
+	}
+	if len(story.State().EvaluationStack) != 0 || story.State().GetCallStack().GetDepth() != 1 {
+		t.Errorf("Expected a failed call to leave the state unchanged")
+	}
+}
+
+func TestEvaluateFunctionThatStopsEarly(t *testing.T) {
+	// Hello
+	// World
+	// === function broken() ===
+	// ~ inner()
+	// ~ return 1
+	// === function inner() ===
+	// Inner
+	// -> DONE
+	jsonStr := `{"root": [["^Hello", "\n", "^World", "\n", "done"], "done", {
+		"broken": ["ev", {"f()": "inner"}, "pop", "/ev", "ev", 1, "/ev", "~ret", null],
+		"inner": ["^Inner", "\n", "done", null]}], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	if _, err := story.Continue(); err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+
+	if _, _, err := story.EvaluateFunction("broken"); err == nil {
+		t.Fatalf("Expected an error for a function that doesn't return")
+	}
+
+	// The main story is left as it was, and carries on
+	if story.CurrentText() != "Hello\n" {
+		t.Errorf("Expected current text to be restored, got %q", story.CurrentText())
+	}
+	if len(story.State().EvaluationStack) != 0 {
+		t.Errorf("Expected an empty evaluation stack, got %v", story.State().EvaluationStack)
+	}
+	if story.State().GetCallStack().GetDepth() != 1 {
+		t.Errorf("Expected the function frames to be popped, got depth %d", story.State().GetCallStack().GetDepth())
+	}
+	text, err := story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "World\n" {
+		t.Errorf("Expected %q, got %q", "World\n", text)
+	}
+}
@@ -4,12 +4,37 @@ import (
 	"fmt"
 )
 
+// externalFunctionDef is a bound external function and its options.
+type externalFunctionDef struct {
+	function      ExternalFunction
+	lookaheadSafe bool
+}
+
+// ExternalFunctionOption configures an external function binding.
+type ExternalFunctionOption func(*externalFunctionDef)
+
+// LookaheadSafe sets whether the function may be called while the story is
+// evaluating content ahead of the current line, e.g. to check whether glue
+// joins it to the next one. Functions are lookahead safe by default. A
+// function with side effects, such as playing a sound, should pass false: it
+// is then only called for content that is really output, and can't be called
+// while building a string such as choice text.
+func LookaheadSafe(safe bool) ExternalFunctionOption {
+	return func(def *externalFunctionDef) {
+		def.lookaheadSafe = safe
+	}
+}
+
 // BindExternalFunction binds a go function to the story.
-func (s *Story) BindExternalFunction(name string, f ExternalFunction) error {
+func (s *Story) BindExternalFunction(name string, f ExternalFunction, opts ...ExternalFunctionOption) error {
 	if _, ok := s.externalFunctions[name]; ok {
 		return fmt.Errorf("function '%s' is already bound", name)
 	}
-	s.externalFunctions[name] = f
+	def := &externalFunctionDef{function: f, lookaheadSafe: true}
+	for _, opt := range opts {
+		opt(def)
+	}
+	s.externalFunctions[name] = def
 	return nil
 }
 
@@ -18,45 +43,79 @@ func (s *Story) UnbindExternalFunction(name string) {
 	delete(s.externalFunctions, name)
 }
 
-// callExternalFunction calls a bound external function.
+// callExternalFunction calls a bound external function. If no Go function is
+// bound and AllowExternalFunctionFallbacks is set, it diverts into the ink
+// function of the same name instead.
+//
+// A call always consumes its arguments and pushes a single result, Void if
+// the function returned nil or failed, so the evaluation stack stays
+// balanced when an error is returned.
 func (s *Story) callExternalFunction(name string, numberOfArgs int) error {
-	f, ok := s.externalFunctions[name]
-	if !ok {
-		// If function isn't found, we might fallback?
-		// In Ink, often there's a fallback function in the ink itself logic.
-		// For now simple error or try fallback logic if implemented.
-		return fmt.Errorf("external function '%s' not found", name)
+	def, ok := s.externalFunctions[name]
+	if !ok && s.AllowExternalFunctionFallbacks {
+		fallback, isContainer := s.MainContent.NamedContent[name].(*Container)
+		if isContainer {
+			// The ink function takes its arguments from the evaluation stack
+			s.state.GetCallStack().Push(PushPopTypeFunction, 0, len(s.state.GetOutputStream()))
+			s.state.SetDivertedPointer(StartOf(fallback))
+			return nil
+		}
+	}
+
+	// While looking ahead past a newline, a function that isn't lookahead
+	// safe stops the lookahead instead, and is called once the story is
+	// rewound and continues for real.
+	if ok && !def.lookaheadSafe && s.stateSnapshotAtLastNewline != nil && !s.state.InStringEvaluation() {
+		s.sawLookaheadUnsafeFunctionAfterNewline = true
+		return nil
 	}
 
 	// Pop arguments
 	args := make([]any, numberOfArgs)
+	var argErr error
 	for i := numberOfArgs - 1; i >= 0; i-- {
 		obj := s.state.PopEvaluationStack()
 		val, err := RuntimeObjectToNative(obj)
-		if err != nil {
-			return fmt.Errorf("failed to convert argument %d for function '%s': %v", i, name, err)
+		if err != nil && argErr == nil {
+			argErr = fmt.Errorf("failed to convert argument %d for function '%s': %v", i, name, err)
 		}
 		args[i] = val
 	}
 
-	// Call
-	ret, err := f(args)
+	ret, err := s.invokeExternalFunction(name, def, args, argErr)
 	if err != nil {
-		return fmt.Errorf("error executing external function '%s': %v", name, err)
+		s.state.PushEvaluationStack(NewVoid())
+		return err
 	}
+	s.state.PushEvaluationStack(ret)
+	return nil
+}
 
-	// Push result
-	if ret != nil {
-		rtObj, err := NativeToRuntimeObject(ret)
-		if err != nil {
-			return fmt.Errorf("failed to convert return value from function '%s': %v", name, err)
-		}
-		if rtObj != nil {
-			s.state.PushEvaluationStack(rtObj)
-		}
+// invokeExternalFunction calls f and converts its result to a runtime object.
+func (s *Story) invokeExternalFunction(name string, def *externalFunctionDef, args []any, argErr error) (RuntimeObject, error) {
+	switch {
+	case def == nil && s.AllowExternalFunctionFallbacks:
+		return nil, fmt.Errorf("trying to call EXTERNAL function '%s' which has not been bound, and fallback ink function could not be found", name)
+	case def == nil:
+		return nil, fmt.Errorf("trying to call EXTERNAL function '%s' which has not been defined (and ink fallbacks disabled)", name)
+	case argErr != nil:
+		return nil, argErr
+	case !def.lookaheadSafe && s.state.InStringEvaluation():
+		return nil, fmt.Errorf("external function %s could not be called because 1) it wasn't marked as lookahead safe when BindExternalFunction was called and 2) the story is in the middle of string generation, either because choice text is being generated, or because you have ink like \"hello {func()}\". You can work around this by generating the result of your function into a temporary variable before the string or choice gets generated: ~ temp x = %s()", name, name)
 	}
 
-	return nil
+	ret, err := def.function(args)
+	if err != nil {
+		return nil, fmt.Errorf("error executing external function '%s': %v", name, err)
+	}
+	if ret == nil {
+		return NewVoid(), nil
+	}
+	rtObj, err := NativeToRuntimeObject(ret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert return value from function '%s': %v", name, err)
+	}
+	return rtObj, nil
 }
 
 // RuntimeObjectToNative converts a RuntimeObject to a native Go value.
@@ -68,8 +127,13 @@ func RuntimeObjectToNative(obj RuntimeObject) (any, error) {
 		return v.Value, nil
 	case *StringValue:
 		return v.Value, nil
-	// case *BoolValue: // If implemented
-	//	return v.Value, nil
+	case *BoolValue:
+		return v.Value, nil
+	case *ListValue:
+		return v.Value, nil
+	case *DivertTargetValue:
+		// Paths aren't public, so divert targets are returned as strings
+		return v.GetTargetPath().String(), nil
 	case *Void:
 		return nil, nil
 	default:
@@ -93,11 +157,9 @@ func NativeToRuntimeObject(val any) (RuntimeObject, error) {
 	case string:
 		return NewStringValue(v), nil
 	case bool:
-		// Assuming we implement BoolValue soon or re-use IntValue(1/0)
-		if v {
-			return NewIntValue(1), nil
-		}
-		return NewIntValue(0), nil
+		return NewBoolValue(v), nil
+	case *List:
+		return NewListValue(v), nil
 	case nil:
 		return nil, nil
 	}
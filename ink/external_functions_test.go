@@ -2,6 +2,7 @@ package ink
 
 import (
 	"fmt"
+	"slices"
 	"testing"
 )
 
@@ -58,3 +59,115 @@ func TestExternalFunctionBinding(t *testing.T) {
 		t.Errorf("External function calc failed. Got '%s', Want '%s'", text, expected)
 	}
 }
+
+func TestExternalFunctionWithoutReturnValue(t *testing.T) {
+	// ~ playSound("ding")
+	// after
+	jsonStr := `{"root": [["ev", "str", "^ding", "/str", {"x()": "playSound", "exArgs": 1}, "pop", "/ev", "^after", "\n", "done"]], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Creation failed: %v", err)
+	}
+
+	var played []any
+	err = story.BindExternalFunction("playSound", func(args []any) (any, error) {
+		played = append(played, args...)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Bind failed: %v", err)
+	}
+
+	text, err := story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "after\n" {
+		t.Errorf("Got %q", text)
+	}
+	if len(played) != 1 || played[0] != "ding" {
+		t.Errorf("Got calls with %v", played)
+	}
+	if len(story.State().EvaluationStack) != 0 {
+		t.Errorf("Expected an empty evaluation stack, got %v", story.State().EvaluationStack)
+	}
+	if story.State().HasError() {
+		t.Errorf("Unexpected errors: %v", story.State().GetCurrentErrors())
+	}
+}
+
+func TestLookaheadUnsafeFunctionInStringEvaluation(t *testing.T) {
+	// * [Take {giveItem()}]
+	jsonStr := `{"root": [["ev", "str", "^Take ", "ev", {"x()": "giveItem"}, "out", "/ev", "/str", "/ev", {"*": ".^.c-0", "flg": 20}, "done", {"c-0": ["done", {"#f": 5}]}], "done", null], "inkVersion": 21}`
+
+	for _, safe := range []bool{true, false} {
+		story, err := NewStory(jsonStr)
+		if err != nil {
+			t.Fatalf("Creation failed: %v", err)
+		}
+		calls := 0
+		err = story.BindExternalFunction("giveItem", func(args []any) (any, error) {
+			calls++
+			return "sword", nil
+		}, LookaheadSafe(safe))
+		if err != nil {
+			t.Fatalf("Bind failed: %v", err)
+		}
+
+		if _, err := story.ContinueMaximally(); err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		if safe {
+			if calls != 1 || story.State().HasError() {
+				t.Errorf("Expected one call and no errors, got %d calls and %v", calls, story.State().GetCurrentErrors())
+			}
+		} else if calls != 0 || !story.State().HasError() {
+			t.Errorf("Expected an unsafe function to be refused in choice text, got %d calls", calls)
+		}
+	}
+}
+
+func TestLookaheadUnsafeFunctionIsCalledOnce(t *testing.T) {
+	// Hello
+	// ~ playSound()
+	// <> world
+	jsonStr := `{"root": [["^Hello", "\n", "ev", {"x()": "playSound"}, "pop", "/ev", "<>", "^ world", "\n", "done"]], "inkVersion": 21}`
+
+	for _, safe := range []bool{true, false} {
+		story, err := NewStory(jsonStr)
+		if err != nil {
+			t.Fatalf("Creation failed: %v", err)
+		}
+		calls := 0
+		err = story.BindExternalFunction("playSound", func(args []any) (any, error) {
+			calls++
+			return nil, nil
+		}, LookaheadSafe(safe))
+		if err != nil {
+			t.Fatalf("Bind failed: %v", err)
+		}
+
+		var lines []string
+		for story.CanContinue() {
+			text, err := story.Continue()
+			if err != nil {
+				t.Fatalf("Continue failed: %v", err)
+			}
+			lines = append(lines, text)
+		}
+
+		// An unsafe function breaks the glue, since the line has to be
+		// finished before the function can be called.
+		want := []string{"Hello world\n"}
+		if !safe {
+			want = []string{"Hello\n", "world\n"}
+		}
+		if !slices.Equal(lines, want) {
+			t.Errorf("LookaheadSafe(%v): got lines %q, want %q", safe, lines, want)
+		}
+		if calls != 1 {
+			t.Errorf("LookaheadSafe(%v): expected 1 call, got %d", safe, calls)
+		}
+	}
+}
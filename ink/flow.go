@@ -1,11 +1,15 @@
 package ink
 
+// DefaultFlowName is the name of the flow a story starts in.
+const DefaultFlowName = "DEFAULT_FLOW"
+
 // Flow represents a flow execution context.
 type Flow struct {
 	Name           string
 	CallStack      *CallStack
 	OutputStream   []RuntimeObject
 	CurrentChoices []*Choice
+	DidEnd         bool
 }
 
 // NewFlow creates a new Flow.
@@ -26,6 +30,7 @@ func (f *Flow) Copy() *Flow {
 		CallStack:      f.CallStack.Copy(),
 		OutputStream:   make([]RuntimeObject, len(f.OutputStream)),
 		CurrentChoices: make([]*Choice, len(f.CurrentChoices)),
+		DidEnd:         f.DidEnd,
 	}
 	// Copy OutputStream (RuntimeObjects are shared except simple values which are effectively immutable)
 	copy(cp.OutputStream, f.OutputStream)
@@ -0,0 +1,62 @@
+package ink
+
+import "testing"
+
+func TestFunctionOutputIsTrimmed(t *testing.T) {
+	// A {f()} B
+	// === function f ===
+	// (blank line)
+	// hello{" "}
+	jsonStr := `{"root": [["^A ", "ev", {"f()": "f"}, "out", "/ev", "^ B", "\n", "done"], "done", {"f": ["\n", "^hello", "^ ", "ev", "void", "/ev", "~ret", null]}], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "A hello B\n" {
+		t.Errorf("Expected %q, got %q", "A hello B\n", text)
+	}
+}
+
+func TestFunctionReturnValue(t *testing.T) {
+	// {double(double(3))}
+	// === function double(x) ===
+	// ~ return x * 2
+	jsonStr := `{"root": [["ev", 3, {"f()": "double"}, {"f()": "double"}, "out", "/ev", "\n", "done"], "done", {"double": [{"temp=": "x"}, "ev", {"VAR?": "x"}, 2, "*", "/ev", "~ret", null]}], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "12\n" {
+		t.Errorf("Expected %q, got %q", "12\n", text)
+	}
+	if len(story.State().EvaluationStack) != 0 {
+		t.Errorf("Expected an empty evaluation stack, got %v", story.State().EvaluationStack)
+	}
+}
+
+func TestReturnOutsideFunctionIsReported(t *testing.T) {
+	jsonStr := `{"root": [["^Hello", "\n", "ev", "void", "/ev", "~ret", "done"], "done", null], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	if _, err := story.ContinueMaximally(); err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	errs := story.State().GetCurrentErrors()
+	want := "Found function return statement (~ return), when expected end of flow (-> END or choice)"
+	if len(errs) != 1 || errs[0] != want {
+		t.Errorf("Expected error %q, got %v", want, errs)
+	}
+}
@@ -46,9 +46,9 @@ func TestGlueBehavior(t *testing.T) {
 		t.Errorf("Case 2 (Forward Glue) Failed. Expected 'CD', got '%s'", output)
 	}
 
-	// Case 3: [Newline, Space, Glue] -> " " (Newline removed, Space kept)
-	// The glue should "look through" the space to kill the newline,
-	// but KEEP the space.
+	// Case 3: [Newline, Space, Glue] -> "" (Newline and space removed)
+	// The glue looks through the space to kill the newline, and removes
+	// the whitespace after it too, as the reference runtime does.
 	s.state.CurrentFlow.OutputStream = []RuntimeObject{}
 	s.state.PushToOutputStream(NewStringValue("E"))
 	s.state.PushToOutputStream(NewStringValue("\n"))
@@ -57,9 +57,7 @@ func TestGlueBehavior(t *testing.T) {
 	s.state.PushToOutputStream(NewStringValue("F"))
 
 	output = s.CurrentText()
-	// Ink standard: Glue kills the newline, but preserves the space.
-	// Result should be "E F"
-	if output != "E F" {
-		t.Errorf("Case 3 (Whitespace Transparency) Failed. Expected 'E F', got '%s'", output)
+	if output != "EF" {
+		t.Errorf("Case 3 (Whitespace Transparency) Failed. Expected 'EF', got '%s'", output)
 	}
 }
@@ -2,6 +2,8 @@ package ink
 
 import (
 	"fmt"
+	"math"
+	"slices"
 	"sort"
 	"strings"
 )
@@ -40,6 +42,10 @@ type List struct {
 	Items map[ListItem]int
 	// Origins of the list items (definitions)
 	Origins []*ListDefinition
+
+	// initialOriginNames names the origins of a list that has no items, so
+	// that an emptied list still knows which definitions it belongs to.
+	initialOriginNames []string
 }
 
 // NewList creates a new empty List.
@@ -66,6 +72,28 @@ func (il *List) Remove(item ListItem) {
 	delete(il.Items, item)
 }
 
+// ListItemValue pairs a list item with its value.
+type ListItemValue struct {
+	Item  ListItem
+	Value int
+}
+
+// OrderedItems returns the items sorted by value, with ties broken by origin
+// name, which is the order ink uses when listing or picking items.
+func (il *List) OrderedItems() []ListItemValue {
+	ordered := make([]ListItemValue, 0, len(il.Items))
+	for k, v := range il.Items {
+		ordered = append(ordered, ListItemValue{Item: k, Value: v})
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].Value == ordered[j].Value {
+			return ordered[i].Item.OriginName < ordered[j].Item.OriginName
+		}
+		return ordered[i].Value < ordered[j].Value
+	})
+	return ordered
+}
+
 // Union returns a new List containing items from both lists.
 func (il *List) Union(other *List) *List {
 	newItems := make(map[ListItem]int)
@@ -92,7 +120,7 @@ func (il *List) Union(other *List) *List {
 		}
 	}
 
-	return &List{Items: newItems, Origins: newOrigins}
+	return &List{Items: newItems, Origins: newOrigins, initialOriginNames: il.initialOriginNames}
 }
 
 // Subtract returns a new List with items from the second list removed from the first.
@@ -109,7 +137,7 @@ func (il *List) Subtract(other *List) *List {
 	newOrigins := make([]*ListDefinition, len(il.Origins))
 	copy(newOrigins, il.Origins)
 
-	return &List{Items: newItems, Origins: newOrigins}
+	return &List{Items: newItems, Origins: newOrigins, initialOriginNames: il.initialOriginNames}
 }
 
 // Intersect returns a new List with items present in both lists.
@@ -124,7 +152,212 @@ func (il *List) Intersect(other *List) *List {
 }
 
 // Has returns true if this list contains all items from the other list.
+// Nothing is contained in an empty list, and an empty list isn't contained
+// in anything.
 func (il *List) Has(other *List) bool {
+	if len(other.Items) == 0 || len(il.Items) == 0 {
+		return false
+	}
+	for k := range other.Items {
+		if !il.Contains(k) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetInitialOriginName sets the origin of an empty list.
+func (il *List) SetInitialOriginName(name string) {
+	il.initialOriginNames = []string{name}
+}
+
+// SetInitialOriginNames sets the origins of an empty list.
+func (il *List) SetInitialOriginNames(names []string) {
+	if names == nil {
+		il.initialOriginNames = nil
+		return
+	}
+	il.initialOriginNames = slices.Clone(names)
+}
+
+// OriginNames returns the names of the lists that the items come from. An
+// empty list returns the origin names it was given initially, if any.
+func (il *List) OriginNames() []string {
+	if len(il.Items) == 0 {
+		return il.initialOriginNames
+	}
+	var names []string
+	for item := range il.Items {
+		if item.OriginName != "" && !slices.Contains(names, item.OriginName) {
+			names = append(names, item.OriginName)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// MaxItem returns the item with the highest value, or the zero ListItemValue
+// if the list is empty. Items from different origins with the same value are
+// tie-broken by origin name, so the result doesn't depend on map order.
+func (il *List) MaxItem() ListItemValue {
+	var maxItem ListItemValue
+	for i, item := range il.OrderedItems() {
+		if i == 0 || item.Value > maxItem.Value {
+			maxItem = item
+		}
+	}
+	return maxItem
+}
+
+// MinItem returns the item with the lowest value, or the zero ListItemValue
+// if the list is empty. Ties are broken as for MaxItem.
+func (il *List) MinItem() ListItemValue {
+	var minItem ListItemValue
+	for i, item := range il.OrderedItems() {
+		if i == 0 || item.Value < minItem.Value {
+			minItem = item
+		}
+	}
+	return minItem
+}
+
+// MaxAsList returns a list containing only the item with the highest value.
+func (il *List) MaxAsList() *List {
+	list := NewList()
+	if len(il.Items) > 0 {
+		maxItem := il.MaxItem()
+		list.Add(maxItem.Item, maxItem.Value)
+	}
+	return list
+}
+
+// MinAsList returns a list containing only the item with the lowest value.
+func (il *List) MinAsList() *List {
+	list := NewList()
+	if len(il.Items) > 0 {
+		minItem := il.MinItem()
+		list.Add(minItem.Item, minItem.Value)
+	}
+	return list
+}
+
+// All returns a list of every item in the origins of this list.
+func (il *List) All() *List {
+	list := NewList()
+	for _, origin := range il.Origins {
+		for name, v := range origin.Items {
+			list.Add(NewListItem(origin.Name, name), v)
+		}
+	}
+	return list
+}
+
+// Inverse returns a list of the items in the origins of this list that aren't
+// in this list.
+func (il *List) Inverse() *List {
+	list := NewList()
+	for _, origin := range il.Origins {
+		for name, v := range origin.Items {
+			item := NewListItem(origin.Name, name)
+			if !il.Contains(item) {
+				list.Add(item, v)
+			}
+		}
+	}
+	return list
+}
+
+// ListWithSubRange returns the items whose values lie between the bounds,
+// inclusive. Each bound is either an int, or a list whose lowest (for the
+// minimum) or highest (for the maximum) value is used.
+func (il *List) ListWithSubRange(minBound, maxBound any) *List {
+	subList := NewList()
+	subList.Origins = append(subList.Origins, il.Origins...)
+	subList.SetInitialOriginNames(il.OriginNames())
+	if len(il.Items) == 0 {
+		return subList
+	}
+
+	minValue := 0
+	maxValue := math.MaxInt
+	switch b := minBound.(type) {
+	case int:
+		minValue = b
+	case *List:
+		if len(b.Items) > 0 {
+			minValue = b.MinItem().Value
+		}
+	}
+	switch b := maxBound.(type) {
+	case int:
+		maxValue = b
+	case *List:
+		if len(b.Items) > 0 {
+			maxValue = b.MaxItem().Value
+		}
+	}
+
+	for k, v := range il.Items {
+		if v >= minValue && v <= maxValue {
+			subList.Add(k, v)
+		}
+	}
+	return subList
+}
+
+// GreaterThan returns true if every item in this list has a higher value
+// than every item in the other list.
+func (il *List) GreaterThan(other *List) bool {
+	if len(il.Items) == 0 {
+		return false
+	}
+	if len(other.Items) == 0 {
+		return true
+	}
+	return il.MinItem().Value > other.MaxItem().Value
+}
+
+// GreaterThanOrEquals returns true if both the lowest and highest values of
+// this list are at least those of the other list.
+func (il *List) GreaterThanOrEquals(other *List) bool {
+	if len(il.Items) == 0 {
+		return false
+	}
+	if len(other.Items) == 0 {
+		return true
+	}
+	return il.MinItem().Value >= other.MinItem().Value && il.MaxItem().Value >= other.MaxItem().Value
+}
+
+// LessThan returns true if every item in this list has a lower value than
+// every item in the other list.
+func (il *List) LessThan(other *List) bool {
+	if len(other.Items) == 0 {
+		return false
+	}
+	if len(il.Items) == 0 {
+		return true
+	}
+	return il.MaxItem().Value < other.MinItem().Value
+}
+
+// LessThanOrEquals returns true if both the lowest and highest values of this
+// list are at most those of the other list.
+func (il *List) LessThanOrEquals(other *List) bool {
+	if len(other.Items) == 0 {
+		return false
+	}
+	if len(il.Items) == 0 {
+		return true
+	}
+	return il.MaxItem().Value <= other.MaxItem().Value && il.MinItem().Value <= other.MinItem().Value
+}
+
+// Equals returns true if both lists contain exactly the same items.
+func (il *List) Equals(other *List) bool {
+	if len(il.Items) != len(other.Items) {
+		return false
+	}
 	for k := range other.Items {
 		if !il.Contains(k) {
 			return false
@@ -165,21 +398,7 @@ func (lv *ListValue) Cast(newType ValueType) (Value, error) {
 
 	switch newType {
 	case ValueTypeInt:
-		// Max item value? Or empty 0 / 1?
-		// Ink logic: defined as Max Item Value if distinct, else...
-		// Simplified: If empty 0, else max value of items
-		maxVal := 0
-		first := true
-		for _, v := range lv.Value.Items {
-			if first || v > maxVal {
-				maxVal = v
-				first = false
-			}
-		}
-		if first { // empty
-			return NewIntValue(0), nil
-		}
-		return NewIntValue(maxVal), nil
+		return NewIntValue(lv.Value.MaxItem().Value), nil
 
 	case ValueTypeFloat:
 		val, _ := lv.Cast(ValueTypeInt)
@@ -188,24 +407,12 @@ func (lv *ListValue) Cast(newType ValueType) (Value, error) {
 		}
 
 	case ValueTypeString:
-		type itemPair struct {
-			k ListItem
-			v int
-		}
-		var sorted []itemPair
-		for k, v := range lv.Value.Items {
-			sorted = append(sorted, itemPair{k, v})
-		}
-		sort.Slice(sorted, func(i, j int) bool {
-			return sorted[i].v < sorted[j].v
-		})
-
 		var sb strings.Builder
-		for i, pair := range sorted {
+		for i, pair := range lv.Value.OrderedItems() {
 			if i > 0 {
 				sb.WriteString(", ")
 			}
-			sb.WriteString(pair.k.ItemName)
+			sb.WriteString(pair.Item.ItemName)
 		}
 		return NewStringValue(sb.String()), nil
 	}
@@ -97,6 +97,10 @@ func JMapToRuntimeObject(jMap map[string]any) (RuntimeObject, error) {
 		return obj, nil
 	}
 
+	if obj, ok := parseTag(jMap); ok {
+		return obj, nil
+	}
+
 	return nil, fmt.Errorf("map tokens not fully implemented yet: %v", jMap)
 }
 
@@ -192,12 +196,40 @@ func parseControlCommand(v string) (RuntimeObject, bool) {
 		return NewControlCommand(CommandTypeNoOp), true
 	case "thread":
 		return NewControlCommand(CommandTypeStartThread), true
+	case "~ret":
+		return NewControlCommand(CommandTypePopFunction), true
 	case "->->":
 		return NewControlCommand(CommandTypePopTunnel), true
 	case VoidName:
 		return NewVoid(), true
 	case "end":
 		return NewControlCommand(CommandTypeEnd), true
+	case "visit":
+		return NewControlCommand(CommandTypeVisitIndex), true
+	case "readc":
+		return NewControlCommand(CommandTypeReadCount), true
+	case "turn":
+		return NewControlCommand(CommandTypeTurns), true
+	case "turns":
+		return NewControlCommand(CommandTypeTurnsSince), true
+	case "rnd":
+		return NewControlCommand(CommandTypeRandom), true
+	case "srnd":
+		return NewControlCommand(CommandTypeSeedRandom), true
+	case "lrnd":
+		return NewControlCommand(CommandTypeListRandom), true
+	case "choiceCnt":
+		return NewControlCommand(CommandTypeChoiceCount), true
+	case "seq":
+		return NewControlCommand(CommandTypeSequenceShuffleIndex), true
+	case "listInt":
+		return NewControlCommand(CommandTypeListFromInt), true
+	case "range":
+		return NewControlCommand(CommandTypeListRange), true
+	case "#":
+		return NewControlCommand(CommandTypeBeginTag), true
+	case "/#":
+		return NewControlCommand(CommandTypeEndTag), true
 	}
 	return nil, false
 }
@@ -229,6 +261,18 @@ func parseVariableOperation(jMap map[string]any) (RuntimeObject, bool) {
 	if v, ok := jMap["VAR?"]; ok {
 		return NewVariableReference(v.(string)), true
 	}
+	if v, ok := jMap["^var"]; ok {
+		contextIndex := -1
+		if ci, ok := jMap["ci"].(float64); ok {
+			contextIndex = int(ci)
+		}
+		return NewVariablePointerValue(v.(string), contextIndex), true
+	}
+	if v, ok := jMap["CNT?"]; ok {
+		readCountRef := NewVariableReference("")
+		readCountRef.PathForCount = NewPathFromString(v.(string))
+		return readCountRef, true
+	}
 	if v, ok := jMap["VAR="]; ok {
 		varName := v.(string)
 		isNewDecl := true
@@ -316,6 +360,15 @@ func parseList(jMap map[string]any) (RuntimeObject, bool) {
 				}
 				inkList.Add(NewListItem(originName, itemName), itemVal)
 			}
+			if origins, ok := jMap["origins"].([]any); ok {
+				names := make([]string, 0, len(origins))
+				for _, o := range origins {
+					if name, ok := o.(string); ok {
+						names = append(names, name)
+					}
+				}
+				inkList.SetInitialOriginNames(names)
+			}
 			return NewListValue(inkList), true
 		}
 	}
@@ -353,6 +406,16 @@ func parseDivertTargetValue(jMap map[string]any) (RuntimeObject, bool) {
 	return nil, false
 }
 
+// parseTag handles the legacy {"#": "text"} tag format.
+func parseTag(jMap map[string]any) (RuntimeObject, bool) {
+	if v, ok := jMap["#"]; ok {
+		if text, ok := v.(string); ok {
+			return NewTag(text), true
+		}
+	}
+	return nil, false
+}
+
 func parseContainerFlags(container *Container, v any) {
 	if flags, ok := v.(float64); ok {
 		f := int(flags)
@@ -25,3 +25,13 @@ func (ld *ListDefinition) ContainsItem(itemName string) bool {
 	_, ok := ld.Items[itemName]
 	return ok
 }
+
+// ItemWithValue returns the item in this list definition with the given value.
+func (ld *ListDefinition) ItemWithValue(value int) (ListItem, bool) {
+	for name, v := range ld.Items {
+		if v == value {
+			return NewListItem(ld.Name, name), true
+		}
+	}
+	return ListItem{}, false
+}
@@ -3,15 +3,44 @@ package ink
 // ListDefinitionsOrigin stores definitions of lists.
 type ListDefinitionsOrigin struct {
 	Lists map[string]*ListDefinition
+
+	// itemsByName maps both full item names (e.g. "Colours.red") and bare
+	// item names to their items. A bare name defined in more than one list
+	// is ambiguous, and maps to nil.
+	itemsByName map[string]*ListItemValue
 }
 
 // NewListDefinitionsOrigin creates a new ListDefinitionsOrigin.
 func NewListDefinitionsOrigin(lists []*ListDefinition) *ListDefinitionsOrigin {
 	origin := &ListDefinitionsOrigin{
-		Lists: make(map[string]*ListDefinition),
+		Lists:       make(map[string]*ListDefinition),
+		itemsByName: make(map[string]*ListItemValue),
 	}
 	for _, l := range lists {
 		origin.Lists[l.Name] = l
+		for itemName, value := range l.Items {
+			item := &ListItemValue{Item: NewListItem(l.Name, itemName), Value: value}
+			origin.itemsByName[l.Name+"."+itemName] = item
+			if _, seen := origin.itemsByName[itemName]; seen {
+				origin.itemsByName[itemName] = nil
+			} else {
+				origin.itemsByName[itemName] = item
+			}
+		}
 	}
 	return origin
 }
+
+// FindSingleItemListWithName returns a list containing just the item with the
+// given name, which may be either the bare item name or its full name
+// (e.g. "Colours.red"). It returns nil if there is no such item, or if a bare
+// name is defined in more than one list and so is ambiguous.
+func (o *ListDefinitionsOrigin) FindSingleItemListWithName(name string) *ListValue {
+	item := o.itemsByName[name]
+	if item == nil {
+		return nil
+	}
+	list := NewList()
+	list.Add(item.Item, item.Value)
+	return NewListValue(list)
+}
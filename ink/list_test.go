@@ -44,3 +44,154 @@ func TestListLogic(t *testing.T) {
 		t.Errorf("List logic failed. Got '%s'", text)
 	}
 }
+
+func TestListFunctions(t *testing.T) {
+	listDefs := `"listDefs": {"Colours": {"red": 1, "green": 2, "blue": 3, "yellow": 4}}`
+	someColours := `{"list": {"Colours.green": 2, "Colours.blue": 3}}`
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected string
+	}{
+		{"Count", someColours + `, "LIST_COUNT"`, "2"},
+		{"Min", someColours + `, "LIST_MIN"`, "green"},
+		{"Max", someColours + `, "LIST_MAX"`, "blue"},
+		{"All", someColours + `, "LIST_ALL"`, "red, green, blue, yellow"},
+		{"Invert", someColours + `, "LIST_INVERT"`, "red, yellow"},
+		{"Value", someColours + `, "LIST_VALUE"`, "3"},
+		{"Value Empty", `{"list": {}}, "LIST_VALUE"`, "0"},
+		{"From Int", `"str", "^Colours", "/str", 3, "listInt"`, "blue"},
+		{"From Int Missing", `"str", "^Colours", "/str", 9, "listInt"`, ""},
+		{"Range Ints", someColours + `, "LIST_ALL", 2, 3, "range"`, "green, blue"},
+		{"Range Lists", someColours + `, "LIST_ALL", {"list": {"Colours.blue": 3}}, {"list": {"Colours.blue": 3}}, "range"`, "blue"},
+		{"Increment", someColours + `, 1, "+"`, "blue, yellow"},
+		{"Decrement Off End", someColours + `, 2, "-"`, "red"},
+		{"Greater", someColours + `, {"list": {"Colours.red": 1}}, ">"`, "true"},
+		{"Less Overlapping", someColours + `, {"list": {"Colours.blue": 3}}, "<"`, "false"},
+		{"Less Or Equal", someColours + `, {"list": {"Colours.blue": 3}}, "<="`, "true"},
+		{"Greater Or Equal", someColours + `, {"list": {"Colours.blue": 3}}, ">="`, "false"},
+		{"Has Empty", someColours + `, {"list": {}}, "?"`, "false"},
+		{"Equal", someColours + `, {"list": {"Colours.blue": 3, "Colours.green": 2}}, "=="`, "true"},
+		{"Empty Equal", `{"list": {}}, {"list": {}}, "=="`, "true"},
+		{"Empty Not Equal", `{"list": {}}, {"list": {}}, "!="`, "false"},
+		{"Empty Versus Non-Empty", `{"list": {}}, ` + someColours + `, "=="`, "false"},
+		{"Non-Empty Versus Empty", someColours + `, {"list": {}}, "!="`, "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonStr := `{"root": [["ev", ` + tt.expr + `, "out", "/ev", "\n", "done", null]], ` + listDefs + `, "inkVersion": 21}`
+			story, err := NewStory(jsonStr)
+			if err != nil {
+				t.Fatalf("Creation failed: %v", err)
+			}
+			text, err := story.Continue()
+			if err != nil {
+				t.Fatalf("Continue failed: %v", err)
+			}
+			if story.State().HasError() {
+				t.Fatalf("Story errors: %v", story.State().GetCurrentErrors())
+			}
+			if text != tt.expected+"\n" {
+				t.Errorf("Expected %q, got %q", tt.expected+"\n", text)
+			}
+		})
+	}
+}
+
+func TestListFunctionErrorsKeepStackBalanced(t *testing.T) {
+	listDefs := `"listDefs": {"Colours": {"red": 1, "green": 2, "blue": 3}}`
+
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"From Int Non-Integer", `"str", "^Colours", "/str", 1.5, "listInt"`},
+		{"From Int Non-String Name", `4, 2, "listInt"`},
+		{"From Int Unknown List", `"str", "^Shapes", "/str", 1, "listInt"`},
+		{"Range Non-List", `3, 1, 2, "range"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The failed result is discarded, leaving 9 to be output
+			jsonStr := `{"root": [["ev", 9, ` + tt.expr + `, "pop", "out", "/ev", "\n", "done", null]], ` + listDefs + `, "inkVersion": 21}`
+			story, err := NewStory(jsonStr)
+			if err != nil {
+				t.Fatalf("Creation failed: %v", err)
+			}
+			text, err := story.Continue()
+			if err != nil {
+				t.Fatalf("Continue failed: %v", err)
+			}
+			if !story.State().HasError() {
+				t.Errorf("Expected an error")
+			}
+			if text != "9\n" {
+				t.Errorf("Expected %q, got %q", "9\n", text)
+			}
+		})
+	}
+}
+
+func TestListMinMaxWithEqualValues(t *testing.T) {
+	// Items from different origins can share a value. The one from the
+	// origin that sorts first is picked, however the map happens to iterate.
+	for i := 0; i < 20; i++ {
+		list := NewList()
+		list.Add(NewListItem("Zoo", "zebra"), 2)
+		list.Add(NewListItem("Farm", "cow"), 2)
+		list.Add(NewListItem("Zoo", "ant"), 1)
+		list.Add(NewListItem("Bugs", "fly"), 1)
+
+		if got := list.MaxItem().Item; got != NewListItem("Farm", "cow") {
+			t.Fatalf("MaxItem() = %v, want Farm.cow", got)
+		}
+		if got := list.MinItem().Item; got != NewListItem("Bugs", "fly") {
+			t.Fatalf("MinItem() = %v, want Bugs.fly", got)
+		}
+	}
+}
+
+func TestFindSingleItemListWithName(t *testing.T) {
+	origin := NewListDefinitionsOrigin([]*ListDefinition{
+		NewListDefinition("Mood", map[string]int{"Happy": 1, "Sad": 2}),
+	})
+
+	for _, name := range []string{"Happy", "Mood.Happy"} {
+		listVal := origin.FindSingleItemListWithName(name)
+		if listVal == nil {
+			t.Fatalf("Expected to find %q", name)
+		}
+		if value, ok := listVal.Value.Items[NewListItem("Mood", "Happy")]; !ok || value != 1 {
+			t.Errorf("%q: got items %v", name, listVal.Value.Items)
+		}
+	}
+
+	if origin.FindSingleItemListWithName("Other.Happy") != nil {
+		t.Errorf("Expected no item for a different origin")
+	}
+	if origin.FindSingleItemListWithName("Angry") != nil {
+		t.Errorf("Expected no item for an unknown name")
+	}
+}
+
+func TestFindSingleItemListWithAmbiguousName(t *testing.T) {
+	origin := NewListDefinitionsOrigin([]*ListDefinition{
+		NewListDefinition("Mood", map[string]int{"Happy": 1, "Calm": 2}),
+		NewListDefinition("Weather", map[string]int{"Calm": 1, "Stormy": 2}),
+	})
+
+	if listVal := origin.FindSingleItemListWithName("Calm"); listVal != nil {
+		t.Errorf("Expected no item for an ambiguous name, got %v", listVal.Value.Items)
+	}
+	for _, name := range []string{"Mood.Calm", "Weather.Calm"} {
+		if origin.FindSingleItemListWithName(name) == nil {
+			t.Errorf("Expected to find %q", name)
+		}
+	}
+	if origin.FindSingleItemListWithName("Happy") == nil {
+		t.Errorf("Expected to find an unambiguous bare name")
+	}
+}
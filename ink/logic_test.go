@@ -55,11 +55,9 @@ func TestLogicEdgeCases(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Story error: %v", err)
 	}
-	// True is usually printed as "1"? Or "true"?
-	// NativeFunctionCall Equal returns NewIntValue(1).
-	// IntValue stringifies to "1".
-	if output != "1" {
-		t.Errorf("Mixed Equality failed: Expected '1', got '%s'", output)
+	// Comparisons return a BoolValue, which is output as "true" like the reference runtime.
+	if output != "true" {
+		t.Errorf("Mixed Equality failed: Expected 'true', got '%s'", output)
 	}
 
 	// 2. String Arithmetic ("val: " + 1)
@@ -91,3 +89,43 @@ func TestLogicEdgeCases(t *testing.T) {
 		t.Errorf("Reverse String Arithmetic failed: Expected '1 val', got '%s'", output3)
 	}
 }
+
+func TestNativeFunctionErrorIsReported(t *testing.T) {
+	// {5 / 0}
+	jsonStr := `{"root": [["ev", 5, 0, "/", "out", "/ev", "\n", "done", null]], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	if _, err := story.Continue(); err != nil {
+		t.Fatalf("Story error: %v", err)
+	}
+	if !story.State().HasError() {
+		t.Fatalf("Expected division by zero to be reported as a story error")
+	}
+	if errs := story.State().GetCurrentErrors(); errs[0] != "division by zero" {
+		t.Errorf("Got errors %q", errs)
+	}
+}
+
+func TestNativeFunctionErrorKeepsStackBalanced(t *testing.T) {
+	// 9 sits below the failed division, and must still be there for the
+	// second out once the failed result has been output as nothing.
+	jsonStr := `{"root": [["ev", 9, 5, 0, "/", "out", "/ev", "^ then ", "ev", "out", "/ev", "\n", "done", null]], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	text, err := story.Continue()
+	if err != nil {
+		t.Fatalf("Story error: %v", err)
+	}
+	if text != "then 9\n" {
+		t.Errorf("Expected %q, got %q", "then 9\n", text)
+	}
+	if errs := story.State().GetCurrentErrors(); len(errs) != 1 || errs[0] != "division by zero" {
+		t.Errorf("Got errors %q", errs)
+	}
+}
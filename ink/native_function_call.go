@@ -2,6 +2,8 @@ package ink
 
 import (
 	"fmt"
+	"math"
+	"strings"
 )
 
 // NativeFunctionCall represents a call to built-in function.
@@ -38,6 +40,12 @@ const (
 	NativeFunctionCallListIntersect       = "^"
 	NativeFunctionCallListHas             = "?"
 	NativeFunctionCallListHasnt           = "!?"
+	NativeFunctionCallCount               = "LIST_COUNT"
+	NativeFunctionCallValueOfList         = "LIST_VALUE"
+	NativeFunctionCallListMin             = "LIST_MIN"
+	NativeFunctionCallListMax             = "LIST_MAX"
+	NativeFunctionCallAll                 = "LIST_ALL"
+	NativeFunctionCallInvert              = "LIST_INVERT"
 )
 
 // NewNativeFunctionCall creates a new NativeFunctionCall.
@@ -58,322 +66,368 @@ func NativeFunctionCallNumberOfParameters(name string) int {
 		NativeFunctionCallAnd, NativeFunctionCallOr, NativeFunctionCallMin, NativeFunctionCallMax, NativeFunctionCallPow,
 		NativeFunctionCallListIntersect, NativeFunctionCallListHas, NativeFunctionCallListHasnt:
 		return 2
-	case NativeFunctionCallNegate, NativeFunctionCallNot, NativeFunctionCallFloor, NativeFunctionCallCeiling, NativeFunctionCallInt, NativeFunctionCallFloat:
+	case NativeFunctionCallNegate, NativeFunctionCallNot, NativeFunctionCallFloor, NativeFunctionCallCeiling, NativeFunctionCallInt, NativeFunctionCallFloat,
+		NativeFunctionCallCount, NativeFunctionCallValueOfList, NativeFunctionCallListMin, NativeFunctionCallListMax, NativeFunctionCallAll, NativeFunctionCallInvert:
 		return 1
 	}
 	return 0
 }
 
-// Call executes the native function.
+// Call executes the native function. Operands are coerced to a single type
+// first, following ink's rules: bools and ints become floats when mixed with
+// floats, and anything mixed with a string becomes a string.
 func (n *NativeFunctionCall) Call(parameters []RuntimeObject) (RuntimeObject, error) {
 	if len(parameters) != n.NumberOfParameters {
 		return nil, fmt.Errorf("unexpected number of parameters")
 	}
 
-	switch n.Name {
-	case NativeFunctionCallAdd:
-		return n.add(parameters[0], parameters[1])
-	case NativeFunctionCallSubtract:
-		return n.subtract(parameters[0], parameters[1])
-	case NativeFunctionCallMultiply:
-		return n.multiply(parameters[0], parameters[1])
-	case NativeFunctionCallDivide:
-		return n.divide(parameters[0], parameters[1])
-	case NativeFunctionCallMod:
-		return n.mod(parameters[0], parameters[1])
-	case NativeFunctionCallEqual:
-		return n.equal(parameters[0], parameters[1])
-	case NativeFunctionCallGreater:
-		return n.greater(parameters[0], parameters[1])
-	case NativeFunctionCallLess:
-		return n.less(parameters[0], parameters[1])
-	case NativeFunctionCallNot:
-		return n.not(parameters[0])
-	case NativeFunctionCallListIntersect:
-		if l1, ok1 := parameters[0].(*ListValue); ok1 {
-			if l2, ok2 := parameters[1].(*ListValue); ok2 {
-				return NewListValue(l1.Value.Intersect(l2.Value)), nil
-			}
+	values := make([]Value, len(parameters))
+	hasList := false
+	for i, p := range parameters {
+		if _, isVoid := p.(*Void); isVoid {
+			return nil, fmt.Errorf("attempting to perform operation on a void value. Did you forget to 'return' a value from a function you called here?")
 		}
-		return nil, fmt.Errorf("cannot intersect %T and %T", parameters[0], parameters[1])
-	case NativeFunctionCallListHas:
-		if l1, ok1 := parameters[0].(*ListValue); ok1 {
-			if l2, ok2 := parameters[1].(*ListValue); ok2 {
-				return NewBoolValue(l1.Value.Has(l2.Value)), nil
-			}
+		val, ok := p.(Value)
+		if !ok {
+			return nil, fmt.Errorf("operands are not values")
 		}
-		return nil, fmt.Errorf("cannot check Has on %T and %T", parameters[0], parameters[1])
-	case NativeFunctionCallListHasnt:
-		if l1, ok1 := parameters[0].(*ListValue); ok1 {
-			if l2, ok2 := parameters[1].(*ListValue); ok2 {
-				return NewBoolValue(!l1.Value.Has(l2.Value)), nil
-			}
+		if _, isList := val.(*ListValue); isList {
+			hasList = true
 		}
-		return nil, fmt.Errorf("cannot check Hasnt on %T and %T", parameters[0], parameters[1])
+		values[i] = val
 	}
-	return nil, fmt.Errorf("operation not implemented: %s", n.Name)
-}
 
-func (n *NativeFunctionCall) coerceValues(v1, v2 RuntimeObject) (Value, Value, error) {
-	val1, ok1 := v1.(Value)
-	val2, ok2 := v2.(Value)
-	if !ok1 || !ok2 {
-		return nil, nil, fmt.Errorf("operands are not values")
+	// Binary operations on lists are treated outside of the standard coercion rules
+	if len(values) == 2 && hasList {
+		return n.callBinaryListOperation(values[0], values[1])
 	}
-	return val1, val2, nil
-}
-
-func (n *NativeFunctionCall) coerceToString(v RuntimeObject) string {
-	if s, ok := v.(*StringValue); ok {
-		return s.Value
-	}
-	if i, ok := v.(*IntValue); ok {
-		return fmt.Sprintf("%d", i.Value)
-	}
-	if f, ok := v.(*FloatValue); ok {
-		return fmt.Sprintf("%v", f.Value)
-	}
-	return ""
-}
 
-func (n *NativeFunctionCall) performBinaryNumericOp(v1, v2 RuntimeObject, intOp func(int, int) int, floatOp func(float64, float64) float64) (RuntimeObject, error) {
-	val1, val2, err := n.coerceValues(v1, v2)
+	coerced, err := n.coerceValuesToSingleType(values)
 	if err != nil {
 		return nil, err
 	}
 
-	i1, isInt1 := val1.(*IntValue)
-	i2, isInt2 := val2.(*IntValue)
-
-	if isInt1 && isInt2 {
-		return NewIntValue(intOp(i1.Value, i2.Value)), nil
+	switch coerced[0].(type) {
+	case *IntValue:
+		return n.callInt(coerced)
+	case *FloatValue:
+		return n.callFloat(coerced)
+	case *StringValue:
+		return n.callString(coerced)
+	case *DivertTargetValue:
+		return n.callDivertTarget(coerced)
+	case *ListValue:
+		return n.callList(coerced)
 	}
-
-	f1, isFloat1 := val1.(*FloatValue)
-	f2, isFloat2 := val2.(*FloatValue)
-
-	if isFloat1 && isFloat2 {
-		return NewFloatValue(floatOp(f1.Value, f2.Value)), nil
-	}
-	if isInt1 && isFloat2 {
-		return NewFloatValue(floatOp(float64(i1.Value), f2.Value)), nil
-	}
-	if isFloat1 && isInt2 {
-		return NewFloatValue(floatOp(f1.Value, float64(i2.Value))), nil
-	}
-
-	return nil, fmt.Errorf("cannot perform operation on %T and %T", v1, v2)
+	return nil, fmt.Errorf("cannot perform operation %s on %T", n.Name, coerced[0])
 }
 
-func (n *NativeFunctionCall) add(v1, v2 RuntimeObject) (RuntimeObject, error) {
-	_, isStr1 := v1.(*StringValue)
-	_, isStr2 := v2.(*StringValue)
-
-	if isStr1 || isStr2 {
-		str1 := n.coerceToString(v1)
-		str2 := n.coerceToString(v2)
-		return NewStringValue(str1 + str2), nil
-	}
-
-	l1, isList1 := v1.(*ListValue)
-	l2, isList2 := v2.(*ListValue)
-	if isList1 && isList2 {
-		return NewListValue(l1.Value.Union(l2.Value)), nil
-	}
-
-	return n.addNumbers(v1, v2)
+// coercionRank orders value types so that the "higher level" type of two
+// operands infects the other, e.g. an int and a float become two floats.
+func coercionRank(t ValueType) int {
+	switch t {
+	case ValueTypeBool:
+		return -1
+	case ValueTypeInt:
+		return 0
+	case ValueTypeFloat:
+		return 1
+	case ValueTypeList:
+		return 2
+	case ValueTypeString:
+		return 3
+	case ValueTypeDivertTarget:
+		return 4
+	case ValueTypeVariablePointer:
+		return 5
+	}
+	return -2
 }
 
-func (n *NativeFunctionCall) addNumbers(v1, v2 RuntimeObject) (RuntimeObject, error) {
-	return n.performBinaryNumericOp(v1, v2,
-		func(a, b int) int { return a + b },
-		func(a, b float64) float64 { return a + b },
-	)
-}
+func (n *NativeFunctionCall) coerceValuesToSingleType(values []Value) ([]Value, error) {
+	// Bools are never operated on directly, they are at least ints
+	valType := ValueTypeInt
+	for _, val := range values {
+		if coercionRank(val.GetValueType()) > coercionRank(valType) {
+			valType = val.GetValueType()
+		}
+	}
 
-func (n *NativeFunctionCall) subtract(v1, v2 RuntimeObject) (RuntimeObject, error) {
-	l1, isList1 := v1.(*ListValue)
-	l2, isList2 := v2.(*ListValue)
-	if isList1 && isList2 {
-		return NewListValue(l1.Value.Subtract(l2.Value)), nil
+	coerced := make([]Value, len(values))
+	for i, val := range values {
+		castValue, err := val.Cast(valType)
+		if err != nil {
+			return nil, err
+		}
+		if castValue == nil {
+			return nil, fmt.Errorf("cannot cast %T to %v", val, valType)
+		}
+		coerced[i] = castValue
 	}
-	return n.performBinaryNumericOp(v1, v2,
-		func(a, b int) int { return a - b },
-		func(a, b float64) float64 { return a - b },
-	)
+	return coerced, nil
 }
 
-func (n *NativeFunctionCall) multiply(v1, v2 RuntimeObject) (RuntimeObject, error) {
-	return n.performBinaryNumericOp(v1, v2,
-		func(a, b int) int { return a * b },
-		func(a, b float64) float64 { return a * b },
-	)
+func (n *NativeFunctionCall) notSupported(typeName string) error {
+	return fmt.Errorf("cannot perform operation %s on %s values", n.Name, typeName)
 }
 
-func (n *NativeFunctionCall) divide(v1, v2 RuntimeObject) (RuntimeObject, error) {
-	val1, val2, err := n.coerceValues(v1, v2)
-	if err != nil {
-		return nil, err
+func (n *NativeFunctionCall) callInt(params []Value) (RuntimeObject, error) {
+	x := params[0].(*IntValue).Value
+	if len(params) == 1 {
+		switch n.Name {
+		case NativeFunctionCallNegate:
+			return NewIntValue(-x), nil
+		case NativeFunctionCallNot:
+			return NewBoolValue(x == 0), nil
+		case NativeFunctionCallFloor, NativeFunctionCallCeiling, NativeFunctionCallInt:
+			return NewIntValue(x), nil
+		case NativeFunctionCallFloat:
+			return NewFloatValue(float64(x)), nil
+		}
+		return nil, n.notSupported("int")
 	}
 
-	i1, isInt1 := val1.(*IntValue)
-	i2, isInt2 := val2.(*IntValue)
-
-	if isInt1 && isInt2 {
-		if i2.Value == 0 {
+	y := params[1].(*IntValue).Value
+	switch n.Name {
+	case NativeFunctionCallAdd:
+		return NewIntValue(x + y), nil
+	case NativeFunctionCallSubtract:
+		return NewIntValue(x - y), nil
+	case NativeFunctionCallMultiply:
+		return NewIntValue(x * y), nil
+	case NativeFunctionCallDivide:
+		if y == 0 {
 			return nil, fmt.Errorf("division by zero")
 		}
-		return NewIntValue(i1.Value / i2.Value), nil
-	}
-
-	f1, isFloat1 := val1.(*FloatValue)
-	f2, isFloat2 := val2.(*FloatValue)
-
-	v1Val := float64(0)
-	v2Val := float64(0)
-	if isInt1 {
-		v1Val = float64(i1.Value)
-	} else if isFloat1 {
-		v1Val = f1.Value
-	}
-	if isInt2 {
-		v2Val = float64(i2.Value)
-	} else if isFloat2 {
-		v2Val = f2.Value
-	}
-
-	if v2Val == 0 {
-		return nil, fmt.Errorf("division by zero")
-	}
-	return NewFloatValue(v1Val / v2Val), nil
-}
-
-func (n *NativeFunctionCall) mod(v1, v2 RuntimeObject) (RuntimeObject, error) {
-	val1, val2, err := n.coerceValues(v1, v2)
-	if err != nil {
-		return nil, err
-	}
-
-	i1, isInt1 := val1.(*IntValue)
-	i2, isInt2 := val2.(*IntValue)
-
-	if isInt1 && isInt2 {
-		if i2.Value == 0 {
+		return NewIntValue(x / y), nil
+	case NativeFunctionCallMod:
+		if y == 0 {
 			return nil, fmt.Errorf("modulo by zero")
 		}
-		return NewIntValue(i1.Value % i2.Value), nil
-	}
-	return nil, fmt.Errorf("modulo not supported for non-integers")
+		return NewIntValue(x % y), nil
+	case NativeFunctionCallEqual:
+		return NewBoolValue(x == y), nil
+	case NativeFunctionCallGreater:
+		return NewBoolValue(x > y), nil
+	case NativeFunctionCallLess:
+		return NewBoolValue(x < y), nil
+	case NativeFunctionCallGreaterThanOrEquals:
+		return NewBoolValue(x >= y), nil
+	case NativeFunctionCallLessThanOrEquals:
+		return NewBoolValue(x <= y), nil
+	case NativeFunctionCallNotEquals:
+		return NewBoolValue(x != y), nil
+	case NativeFunctionCallAnd:
+		return NewBoolValue(x != 0 && y != 0), nil
+	case NativeFunctionCallOr:
+		return NewBoolValue(x != 0 || y != 0), nil
+	case NativeFunctionCallMax:
+		return NewIntValue(max(x, y)), nil
+	case NativeFunctionCallMin:
+		return NewIntValue(min(x, y)), nil
+	case NativeFunctionCallPow:
+		return NewFloatValue(math.Pow(float64(x), float64(y))), nil
+	}
+	return nil, n.notSupported("int")
 }
 
-func (n *NativeFunctionCall) equalNumbers(v1, v2 RuntimeObject) (int, bool) {
-	i1, isInt1 := v1.(*IntValue)
-	i2, isInt2 := v2.(*IntValue)
-	if isInt1 && isInt2 {
-		if i1.Value == i2.Value {
-			return 1, true
+func (n *NativeFunctionCall) callFloat(params []Value) (RuntimeObject, error) {
+	x := params[0].(*FloatValue).Value
+	if len(params) == 1 {
+		switch n.Name {
+		case NativeFunctionCallNegate:
+			return NewFloatValue(-x), nil
+		case NativeFunctionCallNot:
+			return NewBoolValue(x == 0), nil
+		case NativeFunctionCallFloor:
+			return NewFloatValue(math.Floor(x)), nil
+		case NativeFunctionCallCeiling:
+			return NewFloatValue(math.Ceil(x)), nil
+		case NativeFunctionCallInt:
+			return NewIntValue(int(x)), nil
+		case NativeFunctionCallFloat:
+			return NewFloatValue(x), nil
 		}
-		return 0, true
+		return nil, n.notSupported("float")
 	}
 
-	f1, isFloat1 := v1.(*FloatValue)
-	f2, isFloat2 := v2.(*FloatValue)
-	if isFloat1 && isFloat2 {
-		if f1.Value == f2.Value {
-			return 1, true
-		}
-		return 0, true
-	}
+	y := params[1].(*FloatValue).Value
+	switch n.Name {
+	case NativeFunctionCallAdd:
+		return NewFloatValue(x + y), nil
+	case NativeFunctionCallSubtract:
+		return NewFloatValue(x - y), nil
+	case NativeFunctionCallMultiply:
+		return NewFloatValue(x * y), nil
+	case NativeFunctionCallDivide:
+		return NewFloatValue(x / y), nil
+	case NativeFunctionCallMod:
+		return NewFloatValue(math.Mod(x, y)), nil
+	case NativeFunctionCallEqual:
+		return NewBoolValue(x == y), nil
+	case NativeFunctionCallGreater:
+		return NewBoolValue(x > y), nil
+	case NativeFunctionCallLess:
+		return NewBoolValue(x < y), nil
+	case NativeFunctionCallGreaterThanOrEquals:
+		return NewBoolValue(x >= y), nil
+	case NativeFunctionCallLessThanOrEquals:
+		return NewBoolValue(x <= y), nil
+	case NativeFunctionCallNotEquals:
+		return NewBoolValue(x != y), nil
+	case NativeFunctionCallAnd:
+		return NewBoolValue(x != 0 && y != 0), nil
+	case NativeFunctionCallOr:
+		return NewBoolValue(x != 0 || y != 0), nil
+	case NativeFunctionCallMax:
+		return NewFloatValue(math.Max(x, y)), nil
+	case NativeFunctionCallMin:
+		return NewFloatValue(math.Min(x, y)), nil
+	case NativeFunctionCallPow:
+		return NewFloatValue(math.Pow(x, y)), nil
+	}
+	return nil, n.notSupported("float")
+}
 
-	if isInt1 && isFloat2 {
-		if float64(i1.Value) == f2.Value {
-			return 1, true
-		}
-		return 0, true
+func (n *NativeFunctionCall) callString(params []Value) (RuntimeObject, error) {
+	if len(params) != 2 {
+		return nil, n.notSupported("string")
 	}
-	if isFloat1 && isInt2 {
-		if f1.Value == float64(i2.Value) {
-			return 1, true
-		}
-		return 0, true
+
+	x := params[0].(*StringValue).Value
+	y := params[1].(*StringValue).Value
+	switch n.Name {
+	case NativeFunctionCallAdd:
+		return NewStringValue(x + y), nil
+	case NativeFunctionCallEqual:
+		return NewBoolValue(x == y), nil
+	case NativeFunctionCallNotEquals:
+		return NewBoolValue(x != y), nil
+	case NativeFunctionCallListHas:
+		return NewBoolValue(strings.Contains(x, y)), nil
+	case NativeFunctionCallListHasnt:
+		return NewBoolValue(!strings.Contains(x, y)), nil
 	}
-	return 0, false
+	return nil, n.notSupported("string")
 }
 
-func (n *NativeFunctionCall) equal(v1, v2 RuntimeObject) (RuntimeObject, error) {
-	if v1 == nil && v2 == nil {
-		return NewIntValue(1), nil
-	}
-	if v1 == nil || v2 == nil {
-		return NewIntValue(0), nil
+func (n *NativeFunctionCall) callDivertTarget(params []Value) (RuntimeObject, error) {
+	if len(params) != 2 {
+		return nil, n.notSupported("divert target")
 	}
 
-	val1, ok1 := v1.(Value)
-	val2, ok2 := v2.(Value)
-
-	if !ok1 || !ok2 {
-		return NewIntValue(0), nil
+	x := params[0].(*DivertTargetValue).GetTargetPath().String()
+	y := params[1].(*DivertTargetValue).GetTargetPath().String()
+	switch n.Name {
+	case NativeFunctionCallEqual:
+		return NewBoolValue(x == y), nil
+	case NativeFunctionCallNotEquals:
+		return NewBoolValue(x != y), nil
 	}
+	return nil, n.notSupported("divert target")
+}
 
-	if eq, ok := n.equalNumbers(val1, val2); ok {
-		return NewIntValue(eq), nil
+// callBinaryListOperation handles binary operations where at least one of the
+// operands is a list.
+func (n *NativeFunctionCall) callBinaryListOperation(v1, v2 Value) (RuntimeObject, error) {
+	listVal, isList1 := v1.(*ListValue)
+	_, isList2 := v2.(*ListValue)
+
+	// List-int addition/subtraction shifts each item, e.g. "alpha" + 1 = "beta"
+	if intVal, isInt := v2.(*IntValue); isList1 && isInt && (n.Name == NativeFunctionCallAdd || n.Name == NativeFunctionCallSubtract) {
+		return n.callListIncrementOperation(listVal.Value, intVal.Value), nil
 	}
 
-	s1, isStr1 := val1.(*StringValue)
-	s2, isStr2 := val2.(*StringValue)
-	if isStr1 && isStr2 {
-		if s1.Value == s2.Value {
-			return NewIntValue(1), nil
+	// And/or with any other type requires coercion to bool
+	if (n.Name == NativeFunctionCallAnd || n.Name == NativeFunctionCallOr) && (!isList1 || !isList2) {
+		if n.Name == NativeFunctionCallAnd {
+			return NewBoolValue(v1.IsTruthy() && v2.IsTruthy()), nil
 		}
-		return NewIntValue(0), nil
+		return NewBoolValue(v1.IsTruthy() || v2.IsTruthy()), nil
 	}
 
-	return NewIntValue(0), nil
+	if isList1 && isList2 {
+		return n.callList([]Value{v1, v2})
+	}
+	return nil, fmt.Errorf("can not call use '%s' operation on %T and %T", n.Name, v1, v2)
 }
 
-func (n *NativeFunctionCall) greater(v1, v2 RuntimeObject) (RuntimeObject, error) {
-	val1, val2, err := n.coerceValues(v1, v2)
-	if err != nil {
-		return nil, err
+// callListIncrementOperation moves each item of the list up or down by the
+// given amount within its origin list. Items that fall off the end are dropped.
+func (n *NativeFunctionCall) callListIncrementOperation(list *List, delta int) RuntimeObject {
+	if n.Name == NativeFunctionCallSubtract {
+		delta = -delta
 	}
 
-	i1, isInt1 := val1.(*IntValue)
-	i2, isInt2 := val2.(*IntValue)
-
-	if isInt1 && isInt2 {
-		if i1.Value > i2.Value {
-			return NewIntValue(1), nil
+	result := NewList()
+	for item, value := range list.Items {
+		target := value + delta
+		for _, origin := range list.Origins {
+			if origin.Name != item.OriginName {
+				continue
+			}
+			if incremented, ok := origin.ItemWithValue(target); ok {
+				result.Add(incremented, target)
+			}
+			break
 		}
-		return NewIntValue(0), nil
 	}
-	return NewIntValue(0), nil
+	return NewListValue(result)
 }
 
-func (n *NativeFunctionCall) less(v1, v2 RuntimeObject) (RuntimeObject, error) {
-	val1, val2, err := n.coerceValues(v1, v2)
-	if err != nil {
-		return nil, err
-	}
-
-	i1, isInt1 := val1.(*IntValue)
-	i2, isInt2 := val2.(*IntValue)
-
-	if isInt1 && isInt2 {
-		if i1.Value < i2.Value {
-			return NewIntValue(1), nil
+func (n *NativeFunctionCall) callList(params []Value) (RuntimeObject, error) {
+	x := params[0].(*ListValue).Value
+	if len(params) == 1 {
+		switch n.Name {
+		case NativeFunctionCallNot:
+			if len(x.Items) == 0 {
+				return NewIntValue(1), nil
+			}
+			return NewIntValue(0), nil
+		case NativeFunctionCallInvert:
+			return NewListValue(x.Inverse()), nil
+		case NativeFunctionCallAll:
+			return NewListValue(x.All()), nil
+		case NativeFunctionCallListMin:
+			return NewListValue(x.MinAsList()), nil
+		case NativeFunctionCallListMax:
+			return NewListValue(x.MaxAsList()), nil
+		case NativeFunctionCallCount:
+			return NewIntValue(len(x.Items)), nil
+		case NativeFunctionCallValueOfList:
+			return NewIntValue(x.MaxItem().Value), nil
 		}
-		return NewIntValue(0), nil
+		return nil, n.notSupported("list")
 	}
-	return NewIntValue(0), nil
-}
 
-func (n *NativeFunctionCall) not(v1 RuntimeObject) (RuntimeObject, error) {
-	val, ok := v1.(Value)
-	if !ok {
-		return nil, fmt.Errorf("operand is not a value")
-	}
-	if val.IsTruthy() {
-		return NewIntValue(0), nil
-	}
-	return NewIntValue(1), nil
+	y := params[1].(*ListValue).Value
+	switch n.Name {
+	case NativeFunctionCallAdd:
+		return NewListValue(x.Union(y)), nil
+	case NativeFunctionCallSubtract:
+		return NewListValue(x.Subtract(y)), nil
+	case NativeFunctionCallListHas:
+		return NewBoolValue(x.Has(y)), nil
+	case NativeFunctionCallListHasnt:
+		return NewBoolValue(!x.Has(y)), nil
+	case NativeFunctionCallListIntersect:
+		return NewListValue(x.Intersect(y)), nil
+	case NativeFunctionCallEqual:
+		return NewBoolValue(x.Equals(y)), nil
+	case NativeFunctionCallNotEquals:
+		return NewBoolValue(!x.Equals(y)), nil
+	case NativeFunctionCallGreater:
+		return NewBoolValue(x.GreaterThan(y)), nil
+	case NativeFunctionCallLess:
+		return NewBoolValue(x.LessThan(y)), nil
+	case NativeFunctionCallGreaterThanOrEquals:
+		return NewBoolValue(x.GreaterThanOrEquals(y)), nil
+	case NativeFunctionCallLessThanOrEquals:
+		return NewBoolValue(x.LessThanOrEquals(y)), nil
+	case NativeFunctionCallAnd:
+		return NewBoolValue(len(x.Items) > 0 && len(y.Items) > 0), nil
+	case NativeFunctionCallOr:
+		return NewBoolValue(len(x.Items) > 0 || len(y.Items) > 0), nil
+	}
+	return nil, n.notSupported("list")
 }
@@ -9,7 +9,7 @@ func TestNativeFunctionMathVerification(t *testing.T) {
 		name     string
 		funcName string
 		args     []RuntimeObject
-		want     interface{} // int or float64 or string error substring
+		want     interface{} // int, float64, bool or string error substring
 		wantErr  bool
 	}{
 		// Add
@@ -38,10 +38,43 @@ func TestNativeFunctionMathVerification(t *testing.T) {
 		{"Mod Ints", "%", []RuntimeObject{NewIntValue(10), NewIntValue(3)}, 1, false},
 		{"Mod Zero", "%", []RuntimeObject{NewIntValue(10), NewIntValue(0)}, "modulo by zero", true},
 
+		{"Mod Negative", "%", []RuntimeObject{NewIntValue(-7), NewIntValue(3)}, -1, false},
+		{"Mod Floats", "%", []RuntimeObject{NewFloatValue(7.5), NewIntValue(2)}, 1.5, false},
+
 		// Equality
-		{"Eq Ints", "==", []RuntimeObject{NewIntValue(5), NewIntValue(5)}, 1, false},
-		{"Neq Ints", "==", []RuntimeObject{NewIntValue(5), NewIntValue(6)}, 0, false},
-		{"Eq Mixed", "==", []RuntimeObject{NewIntValue(5), NewFloatValue(5.0)}, 1, false},
+		{"Eq Ints", "==", []RuntimeObject{NewIntValue(5), NewIntValue(5)}, true, false},
+		{"Neq Ints", "==", []RuntimeObject{NewIntValue(5), NewIntValue(6)}, false, false},
+		{"Eq Mixed", "==", []RuntimeObject{NewIntValue(5), NewFloatValue(5.0)}, true, false},
+		{"Eq Strings", "==", []RuntimeObject{NewStringValue("a"), NewStringValue("a")}, true, false},
+		{"Eq String Int", "==", []RuntimeObject{NewStringValue("5"), NewIntValue(5)}, true, false},
+		{"Not Equals", "!=", []RuntimeObject{NewIntValue(5), NewIntValue(6)}, true, false},
+
+		// Comparison
+		{"Greater Mixed", ">", []RuntimeObject{NewFloatValue(5.5), NewIntValue(5)}, true, false},
+		{"Less Or Equal", "<=", []RuntimeObject{NewIntValue(5), NewIntValue(5)}, true, false},
+		{"Greater Or Equal", ">=", []RuntimeObject{NewIntValue(4), NewIntValue(5)}, false, false},
+		{"Bool Greater", ">", []RuntimeObject{NewBoolValue(true), NewIntValue(0)}, true, false},
+
+		// Logic
+		{"And", "&&", []RuntimeObject{NewIntValue(1), NewBoolValue(false)}, false, false},
+		{"Or", "||", []RuntimeObject{NewIntValue(0), NewFloatValue(0.5)}, true, false},
+		{"Not", "!", []RuntimeObject{NewIntValue(0)}, true, false},
+		{"String Has", "?", []RuntimeObject{NewStringValue("hello"), NewStringValue("ell")}, true, false},
+
+		// Maths functions
+		{"Min Ints", "MIN", []RuntimeObject{NewIntValue(3), NewIntValue(2)}, 2, false},
+		{"Max Mixed", "MAX", []RuntimeObject{NewIntValue(3), NewFloatValue(3.5)}, 3.5, false},
+		{"Pow Ints", "POW", []RuntimeObject{NewIntValue(2), NewIntValue(3)}, 8.0, false},
+		{"Floor Float", "FLOOR", []RuntimeObject{NewFloatValue(1.5)}, 1.0, false},
+		{"Ceiling Float", "CEILING", []RuntimeObject{NewFloatValue(1.2)}, 2.0, false},
+		{"Int Float", "INT", []RuntimeObject{NewFloatValue(-1.7)}, -1, false},
+		{"Float Int", "FLOAT", []RuntimeObject{NewIntValue(2)}, 2.0, false},
+		{"Negate Int", "_", []RuntimeObject{NewIntValue(2)}, -2, false},
+		{"Negate Float", "_", []RuntimeObject{NewFloatValue(2.5)}, -2.5, false},
+
+		// Errors
+		{"Void Operand", "+", []RuntimeObject{NewIntValue(1), NewVoid()}, "void value", true},
+		{"Divert Target And Int", "==", []RuntimeObject{NewDivertTargetValue(NewPathFromString("knot")), NewIntValue(1)}, "cannot cast", true},
 	}
 
 	for _, tt := range tests {
@@ -77,6 +110,14 @@ func TestNativeFunctionMathVerification(t *testing.T) {
 				} else {
 					t.Errorf("got FloatValue, want %T", tt.want)
 				}
+			case *BoolValue:
+				if wantBool, ok := tt.want.(bool); ok {
+					if v.Value != wantBool {
+						t.Errorf("got %t, want %t", v.Value, wantBool)
+					}
+				} else {
+					t.Errorf("got BoolValue, want %T", tt.want)
+				}
 			default:
 				t.Errorf("unexpected return type: %T", got)
 			}
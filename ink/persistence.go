@@ -1,60 +1,98 @@
 package ink
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
+	"slices"
+	"strings"
 )
 
-// ToJSON serializes the story state to a JSON string.
+const (
+	// inkSaveStateVersion is the version of the save format written by ToJSON.
+	inkSaveStateVersion = 10
+	// minCompatibleLoadVersion is the oldest save format LoadState accepts.
+	minCompatibleLoadVersion = 8
+)
+
+// ToJSON serializes the story state to a JSON string, following the layout
+// of the reference runtimes' save format. Loading the result in those
+// runtimes hasn't been verified.
 func (s *Story) ToJSON() (string, error) {
 	dto := s.stateToDto()
 
-	// Marshal to JSON
-	// We use standard Marshal. For Indent, user can unmarshal and marshal again if needed.
-	// Or we can use MarshalIndent if readable output is preferred (often the case for Save Games).
-	// But let's stick to standard compact or maybe indent?
-	// Ink code often produces non-indented JSON for size, but for debugging indentation is nice.
-	// Java implementation uses SimpleJson which defaults to compact unless configured?
-	// Let's use compact for now.
-	bytes, err := json.Marshal(dto)
-	if err != nil {
+	// The reference runtimes don't escape HTML characters in strings
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(dto); err != nil {
 		return "", err
 	}
-	return string(bytes), nil
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
+
+// jsonFloat is a float written the way the reference runtimes write it:
+// always with a decimal point or exponent, so that a whole-number float such
+// as 5.0 loads back as a float rather than an int.
+type jsonFloat float64
+
+// MarshalJSON implements json.Marshaler.
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	switch {
+	case math.IsInf(v, 1):
+		return []byte("3.4E+38"), nil
+	case math.IsInf(v, -1):
+		return []byte("-3.4E+38"), nil
+	case math.IsNaN(v):
+		return []byte("0.0"), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.ContainsAny(b, ".eE") {
+		b = append(b, ".0"...)
+	}
+	return b, nil
 }
 
 func (s *Story) stateToDto() *StoryStateDto {
 	ss := s.state
 
 	dto := &StoryStateDto{
-		InkSaveVersion:   10, // kInkSaveStateVersion
+		InkSaveVersion:   inkSaveStateVersion,
 		InkFormatVersion: 21, // inkVersionCurrent
-		Flows:            make(map[string]FlowDto),
-		VariablesState:   make(map[string]interface{}),
-		VisitCounts:      make(map[string]int),
-		TurnIndices:      make(map[string]int),
+		Flows:            make(OrderedObjectDto[FlowDto], 0),
+		VariablesState:   make(VariablesStateDto, 0),
+		VisitCounts:      make(OrderedObjectDto[int], 0),
+		TurnIndices:      make(OrderedObjectDto[int], 0),
 	}
 
-	// Flows
-	for name, flow := range ss.NamedFlows {
-		flowDto := flowToDto(flow)
-		dto.Flows[name] = flowDto
+	// Flows, in the order they were created
+	for _, name := range orderedKeys(ss.NamedFlows, ss.flowNames, strings.Compare) {
+		dto.Flows = append(dto.Flows, NamedDto[FlowDto]{Name: name, Value: flowToDto(ss.NamedFlows[name])})
 	}
 
 	// Current Flow Name
 	if ss.CurrentFlow != nil {
 		dto.CurrentFlowName = ss.CurrentFlow.Name
 	} else {
-		dto.CurrentFlowName = "DEFAULT_FLOW"
+		dto.CurrentFlowName = DefaultFlowName
 	}
 
 	// VariablesState
-	// Note: We might want option to skip default values (optimization), but for now we save all.
-	for name, val := range ss.VariablesState.GlobalVariables {
-		// Optimization: Check if value equals default global value?
-		// We skip this optimization for simplicity for now as per instructions (Map -> Dto).
+	// Like the reference runtimes, globals that still have their default value
+	// aren't saved. LoadState fills them back in from the defaults.
+	vs := ss.VariablesState
+	for _, name := range s.globalVariableOrder() {
+		val := vs.GlobalVariables[name]
+		if defaultVal, ok := vs.DefaultGlobalVariables[name]; ok && runtimeValuesEqual(val, defaultVal) {
+			continue
+		}
 		vDto := runtimeObjectToInterface(val)
-		dto.VariablesState[name] = vDto
+		dto.VariablesState = append(dto.VariablesState, NamedValueDto{Name: name, Value: vDto})
 	}
 
 	// EvalStack
@@ -66,17 +104,15 @@ func (s *Story) stateToDto() *StoryStateDto {
 		dto.CurrentDivertTarget = ss.DivertedPointer.Path().String()
 	}
 
-	// VisitCounts
-	for container, count := range ss.VisitCounts {
+	// VisitCounts and TurnIndices, in the order containers were first counted
+	for _, container := range orderedKeys(ss.VisitCounts, ss.visitCountOrder, compareContainerPaths) {
 		if container != nil && container.GetPath() != nil {
-			dto.VisitCounts[container.GetPath().String()] = count
+			dto.VisitCounts = append(dto.VisitCounts, NamedDto[int]{Name: container.GetPath().String(), Value: ss.VisitCounts[container]})
 		}
 	}
-
-	// TurnIndices
-	for container, idx := range ss.TurnIndices {
+	for _, container := range orderedKeys(ss.TurnIndices, ss.turnIndexOrder, compareContainerPaths) {
 		if container != nil && container.GetPath() != nil {
-			dto.TurnIndices[container.GetPath().String()] = idx
+			dto.TurnIndices = append(dto.TurnIndices, NamedDto[int]{Name: container.GetPath().String(), Value: ss.TurnIndices[container]})
 		}
 	}
 
@@ -87,10 +123,64 @@ func (s *Story) stateToDto() *StoryStateDto {
 	return dto
 }
 
+// globalVariableOrder returns the names of the global variables in the order
+// the reference runtimes save them: the order they're declared in the global
+// declaration section. Any other globals come after, sorted by name.
+func (s *Story) globalVariableOrder() []string {
+	globals := s.state.VariablesState.GlobalVariables
+	names := make([]string, 0, len(globals))
+	if decl, ok := s.MainContent.NamedContent["global decl"].(*Container); ok {
+		for _, obj := range decl.Content {
+			varAss, ok := obj.(*VariableAssignment)
+			if !ok || !varAss.IsGlobal() || !varAss.IsNewDeclaration() {
+				continue
+			}
+			if _, ok := globals[varAss.VariableName()]; ok && !slices.Contains(names, varAss.VariableName()) {
+				names = append(names, varAss.VariableName())
+			}
+		}
+	}
+
+	declared := len(names)
+	for name := range globals {
+		if !slices.Contains(names[:declared], name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names[declared:])
+	return names
+}
+
+// orderedKeys returns the keys of m in the order given, followed by any keys
+// missing from the order, such as ones set directly on an exported map,
+// sorted with cmp.
+func orderedKeys[K comparable, V any](m map[K]V, order []K, cmp func(a, b K) int) []K {
+	keys := make([]K, 0, len(m))
+	seen := make(map[K]bool, len(m))
+	for _, k := range order {
+		if _, ok := m[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+
+	ordered := len(keys)
+	for k := range m {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	slices.SortFunc(keys[ordered:], cmp)
+	return keys
+}
+
+func compareContainerPaths(a, b *Container) int {
+	return strings.Compare(a.GetPath().String(), b.GetPath().String())
+}
+
 func flowToDto(flow *Flow) FlowDto {
 	dto := FlowDto{
-		OutputStream:  make([]interface{}, 0),
-		ChoiceThreads: make(map[string]CallStackThreadDto),
+		OutputStream: make([]interface{}, 0),
 	}
 
 	// CallStack
@@ -132,9 +222,10 @@ func flowToDto(flow *Flow) FlowDto {
 			}
 		}
 
-		if !found && c.ThreadAtGeneration != nil {
+		key := fmt.Sprintf("%d", c.OriginalThreadIndex)
+		if _, written := dto.ChoiceThreads.Get(key); !found && !written && c.ThreadAtGeneration != nil {
 			tDto := threadToDto(c.ThreadAtGeneration)
-			dto.ChoiceThreads[fmt.Sprintf("%d", c.OriginalThreadIndex)] = tDto
+			dto.ChoiceThreads = append(dto.ChoiceThreads, NamedDto[CallStackThreadDto]{Name: key, Value: tDto})
 		}
 	}
 
@@ -185,21 +276,21 @@ func threadToDto(t *CallStackThread) CallStackThreadDto {
 
 	for _, el := range t.CallStack {
 		elDto := CallStackElementDto{
-			Idx:                el.CurrentPointer.Index,
-			Exp:                el.InExpressionEvaluation,
-			Type:               int(el.Type),
-			TemporaryVariables: make(map[string]interface{}),
+			Exp:  el.InExpressionEvaluation,
+			Type: int(el.Type),
 		}
 
-		if !el.CurrentPointer.IsNull() {
-			if el.CurrentPointer.Container != nil && el.CurrentPointer.Container.GetPath() != nil {
-				elDto.CPath = el.CurrentPointer.Container.GetPath().String()
-			}
+		if !el.CurrentPointer.IsNull() && el.CurrentPointer.Container.GetPath() != nil {
+			cPath := el.CurrentPointer.Container.GetPath().String()
+			idx := el.CurrentPointer.Index
+			elDto.CPath = &cPath
+			elDto.Idx = &idx
 		}
 
-		for name, val := range el.TemporaryVariables {
-			vDto := runtimeObjectToInterface(val)
-			elDto.TemporaryVariables[name] = vDto
+		// Temporaries, in the order they were declared
+		for _, name := range orderedKeys(el.TemporaryVariables, el.temporaryVariableNames, strings.Compare) {
+			vDto := runtimeObjectToInterface(el.TemporaryVariables[name])
+			elDto.TemporaryVariables = append(elDto.TemporaryVariables, NamedValueDto{Name: name, Value: vDto})
 		}
 
 		dto.CallStack = append(dto.CallStack, elDto)
@@ -235,7 +326,7 @@ func runtimeObjectToInterface(obj RuntimeObject) interface{} {
 	case *IntValue:
 		return v.Value
 	case *FloatValue:
-		return v.Value
+		return jsonFloat(v.Value)
 	case *StringValue:
 		if v.GetIsNewline() {
 			return "\n"
@@ -259,9 +350,15 @@ func runtimeObjectToInterface(obj RuntimeObject) interface{} {
 	case *ControlCommand:
 		return controlCommandToString(v.CommandType)
 	case *NativeFunctionCall:
+		// "^" on its own would read back as an empty string
+		if v.Name == "^" {
+			return "L^"
+		}
 		return v.Name
 	case *Void:
 		return VoidName
+	case *Tag:
+		return map[string]string{"#": v.Text}
 	case *ChoicePoint:
 		return map[string]interface{}{
 			"*":   v.PathStringOnChoice,
@@ -291,12 +388,8 @@ func inkListToDto(l *ListValue) map[string]interface{} {
 	}
 
 	// Origins if list is empty
-	if len(rawList.Items) == 0 && len(rawList.Origins) > 0 {
-		names := make([]string, len(rawList.Origins))
-		for i, o := range rawList.Origins {
-			names[i] = o.Name
-		}
-		res["origins"] = names
+	if len(rawList.Items) == 0 && len(rawList.OriginNames()) > 0 {
+		res["origins"] = rawList.OriginNames()
 	}
 
 	return res
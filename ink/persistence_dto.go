@@ -1,28 +1,41 @@
 package ink
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
 // StoryStateDto represents the state of the story for JSON serialization.
 // It strictly mirrors the structure used in the Ink JSON save format.
 type StoryStateDto struct {
-	Flows               map[string]FlowDto     `json:"flows"`
-	CurrentFlowName     string                 `json:"currentFlowName"`
-	VariablesState      map[string]interface{} `json:"variablesState"`
-	EvalStack           []interface{}          `json:"evalStack"`
-	CurrentDivertTarget string                 `json:"currentDivertTarget,omitempty"`
-	VisitCounts         map[string]int         `json:"visitCounts"`
-	TurnIndices         map[string]int         `json:"turnIndices"`
-	TurnIdx             int                    `json:"turnIdx"`
-	StorySeed           int                    `json:"storySeed"`
-	PreviousRandom      int                    `json:"previousRandom"`
-	InkSaveVersion      int                    `json:"inkSaveVersion"`
-	InkFormatVersion    int                    `json:"inkFormatVersion"`
+	Flows               OrderedObjectDto[FlowDto] `json:"flows"`
+	CurrentFlowName     string                    `json:"currentFlowName"`
+	VariablesState      VariablesStateDto         `json:"variablesState"`
+	EvalStack           []interface{}             `json:"evalStack"`
+	CurrentDivertTarget string                    `json:"currentDivertTarget,omitempty"`
+	VisitCounts         OrderedObjectDto[int]     `json:"visitCounts"`
+	TurnIndices         OrderedObjectDto[int]     `json:"turnIndices"`
+	TurnIdx             int                       `json:"turnIdx"`
+	StorySeed           int                       `json:"storySeed"`
+	PreviousRandom      int                       `json:"previousRandom"`
+	InkSaveVersion      int                       `json:"inkSaveVersion"`
+	InkFormatVersion    int                       `json:"inkFormatVersion"`
+
+	// Saves from before flows were added keep the state of the single flow
+	// at the top level instead. These are only read, never written.
+	CallStackThreads *CallStackDto                        `json:"callstackThreads,omitempty"`
+	OutputStream     []interface{}                        `json:"outputStream,omitempty"`
+	CurrentChoices   []ChoiceDto                          `json:"currentChoices,omitempty"`
+	ChoiceThreads    OrderedObjectDto[CallStackThreadDto] `json:"choiceThreads,omitempty"`
 }
 
 // FlowDto represents a saved Flow.
 type FlowDto struct {
-	CallStack      CallStackDto                  `json:"callstack"`
-	OutputStream   []interface{}                 `json:"outputStream"`
-	ChoiceThreads  map[string]CallStackThreadDto `json:"choiceThreads,omitempty"`
-	CurrentChoices []ChoiceDto                   `json:"currentChoices,omitempty"`
+	CallStack      CallStackDto                         `json:"callstack"`
+	OutputStream   []interface{}                        `json:"outputStream"`
+	ChoiceThreads  OrderedObjectDto[CallStackThreadDto] `json:"choiceThreads,omitempty"`
+	CurrentChoices []ChoiceDto                          `json:"currentChoices"`
 }
 
 // ChoiceDto represents a saved Choice.
@@ -48,11 +61,98 @@ type CallStackThreadDto struct {
 	PreviousContentObject string                `json:"previousContentObject,omitempty"`
 }
 
-// CallStackElementDto represents a saved element on the call stack.
+// CallStackElementDto represents a saved element on the call stack. CPath and
+// Idx are only present when the element's pointer isn't null; the root
+// container's path is the empty string.
 type CallStackElementDto struct {
-	CPath              string                 `json:"cPath,omitempty"`
-	Idx                int                    `json:"idx"`
-	Exp                bool                   `json:"exp"`
-	Type               int                    `json:"type"`
-	TemporaryVariables map[string]interface{} `json:"temp,omitempty"`
+	CPath              *string                       `json:"cPath,omitempty"`
+	Idx                *int                          `json:"idx,omitempty"`
+	Exp                bool                          `json:"exp"`
+	Type               int                           `json:"type"`
+	TemporaryVariables OrderedObjectDto[interface{}] `json:"temp,omitempty"`
+}
+
+// OrderedObjectDto is a JSON object that keeps its entries in order, as the
+// reference runtimes write them, rather than in the sorted order Go gives
+// maps.
+type OrderedObjectDto[V any] []NamedDto[V]
+
+// NamedDto is an entry of an OrderedObjectDto.
+type NamedDto[V any] struct {
+	Name  string
+	Value V
+}
+
+// VariablesStateDto holds the saved global variables, in the order they're
+// declared.
+type VariablesStateDto = OrderedObjectDto[interface{}]
+
+// NamedValueDto is a saved global variable.
+type NamedValueDto = NamedDto[interface{}]
+
+// Get returns the value of the named entry.
+func (d OrderedObjectDto[V]) Get(name string) (V, bool) {
+	for _, v := range d {
+		if v.Name == name {
+			return v.Value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d OrderedObjectDto[V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i, v := range d {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(v.Name); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1) // Encode adds a newline
+		buf.WriteByte(':')
+		if err := enc.Encode(v.Value); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping the entries in the
+// order they were saved and numbers as json.Number.
+func (d *OrderedObjectDto[V]) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	*d = nil
+	tok, err := dec.Token()
+	if err != nil || tok == nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("expected an object")
+	}
+
+	*d = OrderedObjectDto[V]{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name, _ := tok.(string)
+		var value V
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		*d = append(*d, NamedDto[V]{Name: name, Value: value})
+	}
+	return nil
 }
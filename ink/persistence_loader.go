@@ -3,18 +3,30 @@ package ink
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"math"
 	"strings"
 )
 
-// LoadState loads the story state from a JSON string.
+// LoadState loads the story state from a JSON string, in the save format
+// of the reference runtimes back to version 8. It's tested against our own
+// saves and hand-written ones, not saves written by those runtimes.
 func (s *Story) LoadState(jsonStr string) error {
 	var dto StoryStateDto
-	err := json.Unmarshal([]byte(jsonStr), &dto)
-	if err != nil {
+	// Keep numbers as written, so that 5.0 still loads as a float
+	dec := json.NewDecoder(strings.NewReader(jsonStr))
+	dec.UseNumber()
+	if err := dec.Decode(&dto); err != nil {
 		return err
 	}
 
+	if dto.InkSaveVersion == 0 {
+		return fmt.Errorf("ink save format incorrect, can't load")
+	}
+	if dto.InkSaveVersion < minCompatibleLoadVersion {
+		return fmt.Errorf("ink save format isn't compatible with the current version (saw '%d', but minimum is %d), so can't load", dto.InkSaveVersion, minCompatibleLoadVersion)
+	}
+
 	return s.restoreStoryState(&dto)
 }
 
@@ -28,13 +40,22 @@ func (s *Story) restoreStoryState(dto *StoryStateDto) error {
 	// We iterate keys in DTO and set them.
 	// But first, we need a working jTokenToRuntimeObject.
 
-	s.state.VariablesState.GlobalVariables = make(map[string]RuntimeObject)
-	for k, vDto := range dto.VariablesState {
-		val, err := s.jTokenToRuntimeObject(vDto)
+	// Globals that still had their default value weren't saved
+	s.state.VariablesState.GlobalVariables = maps.Clone(s.state.VariablesState.DefaultGlobalVariables)
+	if s.state.VariablesState.GlobalVariables == nil {
+		s.state.VariablesState.GlobalVariables = make(map[string]RuntimeObject)
+	}
+	for _, v := range dto.VariablesState {
+		// Like the reference runtimes, only globals the story declares are
+		// loaded, so a save from an older version of the story can't add any
+		if _, ok := s.state.VariablesState.DefaultGlobalVariables[v.Name]; !ok {
+			continue
+		}
+		val, err := s.jTokenToRuntimeObject(v.Value)
 		if err != nil {
-			return fmt.Errorf("failed to load variable '%s': %w", k, err)
+			return fmt.Errorf("failed to load variable '%s': %w", v.Name, err)
 		}
-		s.state.VariablesState.GlobalVariables[k] = val
+		s.state.VariablesState.GlobalVariables[v.Name] = val
 	}
 
 	// Restore EvalStack
@@ -59,23 +80,19 @@ func (s *Story) restoreStoryState(dto *StoryStateDto) error {
 
 	// Restore VisitCounts
 	s.state.VisitCounts = make(map[*Container]int)
-	for k, v := range dto.VisitCounts {
-		p := s.PointerAtPath(NewPathFromString(k))
-		if !p.IsNull() {
-			if c, ok := p.Resolve().(*Container); ok {
-				s.state.VisitCounts[c] = v
-			}
+	s.state.visitCountOrder = nil
+	for _, v := range dto.VisitCounts {
+		if c, ok := s.MainContent.ContentAtPath(NewPathFromString(v.Name)).(*Container); ok {
+			s.state.setVisitCount(c, v.Value)
 		}
 	}
 
 	// Restore TurnIndices
 	s.state.TurnIndices = make(map[*Container]int)
-	for k, v := range dto.TurnIndices {
-		p := s.PointerAtPath(NewPathFromString(k))
-		if !p.IsNull() {
-			if c, ok := p.Resolve().(*Container); ok {
-				s.state.TurnIndices[c] = v
-			}
+	s.state.turnIndexOrder = nil
+	for _, v := range dto.TurnIndices {
+		if c, ok := s.MainContent.ContentAtPath(NewPathFromString(v.Name)).(*Container); ok {
+			s.state.setTurnIndex(c, v.Value)
 		}
 	}
 
@@ -84,27 +101,50 @@ func (s *Story) restoreStoryState(dto *StoryStateDto) error {
 	s.state.PreviousRandom = dto.PreviousRandom
 
 	// Restore Flows
+	flows := dto.Flows
+	if flows == nil {
+		// Older saves only have the default flow, stored at the top level
+		flowDto := FlowDto{
+			OutputStream:   dto.OutputStream,
+			ChoiceThreads:  dto.ChoiceThreads,
+			CurrentChoices: dto.CurrentChoices,
+		}
+		if dto.CallStackThreads != nil {
+			flowDto.CallStack = *dto.CallStackThreads
+		}
+		flows = OrderedObjectDto[FlowDto]{{Name: DefaultFlowName, Value: flowDto}}
+	}
+
 	s.state.NamedFlows = make(map[string]*Flow)
-	for name, flowDto := range dto.Flows {
-		flow, err := s.restoreFlow(&flowDto, name)
+	s.state.flowNames = nil
+	for _, namedFlow := range flows {
+		flow, err := s.restoreFlow(&namedFlow.Value, namedFlow.Name)
 		if err != nil {
-			return fmt.Errorf("failed to restore flow '%s': %w", name, err)
+			return fmt.Errorf("failed to restore flow '%s': %w", namedFlow.Name, err)
+		}
+		if _, ok := s.state.NamedFlows[namedFlow.Name]; !ok {
+			s.state.flowNames = append(s.state.flowNames, namedFlow.Name)
 		}
-		s.state.NamedFlows[name] = flow
+		s.state.NamedFlows[namedFlow.Name] = flow
 	}
 
-	// Set Current Flow
-	if currFlow, ok := s.state.NamedFlows[dto.CurrentFlowName]; ok {
+	// Set Current Flow. With a single flow the reference runtimes ignore
+	// currentFlowName, and older saves don't have it at all.
+	currentFlowName := dto.CurrentFlowName
+	if len(flows) == 1 {
+		currentFlowName = flows[0].Name
+	}
+	if currFlow, ok := s.state.NamedFlows[currentFlowName]; ok {
 		s.state.CurrentFlow = currFlow
-		s.state.CurrentChoices = currFlow.CurrentChoices
+		s.state.VariablesState.SetCallStack(currFlow.CallStack)
 	} else {
 		// Default fallback if not found? Should generally exist.
 		// If explicit "DEFAULT_FLOW" is missing, we might need to create it?
 		// Usually DTO contains it.
-		return fmt.Errorf("current flow '%s' not found in saved flows", dto.CurrentFlowName)
+		return fmt.Errorf("current flow '%s' not found in saved flows", currentFlowName)
 	}
 
-	s.state.OutputStreamDirty = true
+	s.state.markOutputStreamDirty()
 	return nil
 }
 
@@ -142,7 +182,7 @@ func (s *Story) restoreFlow(dto *FlowDto, name string) (*Flow, error) {
 		// Note from Java: "Has to come BEFORE the choices themselves are written out"
 		// In DTO we have them in parallel.
 
-		if threadDto, ok := dto.ChoiceThreads[fmt.Sprintf("%d", c.OriginalThreadIndex)]; ok {
+		if threadDto, ok := dto.ChoiceThreads.Get(fmt.Sprintf("%d", c.OriginalThreadIndex)); ok {
 			threadDto := threadDto // Capture loop variable or map lookups to avoid aliasing issues
 			thread, err := s.restoreThread(&threadDto)
 			if err != nil {
@@ -163,6 +203,7 @@ func (s *Story) restoreFlow(dto *FlowDto, name string) (*Flow, error) {
 
 		flow.CurrentChoices[i] = c
 	}
+	flow.DidEnd = flowDidEnd(flow)
 
 	return flow, nil
 }
@@ -223,35 +264,28 @@ func (s *Story) restoreThread(dto *CallStackThreadDto) (*CallStackThread, error)
 }
 
 func (s *Story) restoreElement(dto *CallStackElementDto) (*CallStackElement, error) {
-	// Reconstruct pointer
-	var p Pointer
-	if dto.CPath != "" {
-		path := NewPathFromString(dto.CPath)
-		p = s.PointerAtPath(path)
-		p.Index = dto.Idx
-
-		if p.IsNull() {
-			// This might happen if CPath is empty string (root?) handled above.
-			// Or if pointer resolution failed.
-			// "root" path is empty components.
-			if dto.CPath == "" && dto.Idx == 0 {
-				p.Container = s.MainContent
-				p.Index = 0
-			}
+	// Reconstruct pointer. An empty path is the root container.
+	p := NullPointer
+	if dto.CPath != nil {
+		container, ok := s.MainContent.ContentAtPath(NewPathFromString(*dto.CPath)).(*Container)
+		if !ok {
+			return nil, fmt.Errorf("when loading state, internal story location couldn't be found: %s. Has the story changed since this save data was created?", *dto.CPath)
+		}
+		p.Container = container
+		if dto.Idx != nil {
+			p.Index = *dto.Idx
 		}
-	} else {
-		p = NullPointer
 	}
 
 	el := NewCallStackElement(PushPopType(dto.Type), p, dto.Exp)
 
 	// Restore temps
-	for k, vDto := range dto.TemporaryVariables {
-		val, err := s.jTokenToRuntimeObject(vDto)
+	for _, v := range dto.TemporaryVariables {
+		val, err := s.jTokenToRuntimeObject(v.Value)
 		if err != nil {
-			return nil, fmt.Errorf("failed to restore temp var '%s': %w", k, err)
+			return nil, fmt.Errorf("failed to restore temp var '%s': %w", v.Name, err)
 		}
-		el.TemporaryVariables[k] = val
+		el.setTemporaryVariable(v.Name, val)
 	}
 
 	return el, nil
@@ -269,6 +303,22 @@ func (s *Story) jTokenToRuntimeObject(token interface{}) (RuntimeObject, error)
 	case bool:
 		return NewBoolValue(val), nil
 
+	case json.Number:
+		// Like the reference runtimes, a number is a float if it's written
+		// with a decimal point or exponent
+		if strings.ContainsAny(val.String(), ".eE") {
+			f, err := val.Float64()
+			if err != nil {
+				return nil, err
+			}
+			return NewFloatValue(f), nil
+		}
+		i, err := val.Int64()
+		if err != nil {
+			return nil, err
+		}
+		return NewIntValue(int(i)), nil
+
 	case float64:
 		// Check if int
 		if val == math.Trunc(val) {
@@ -286,15 +336,16 @@ func (s *Story) jTokenToRuntimeObject(token interface{}) (RuntimeObject, error)
 
 		if name, ok := val["^var"]; ok {
 			ci := -1
-			if ciVal, ok := val["ci"]; ok {
-				// ciVal is float64 likely
-				if f, ok := ciVal.(float64); ok {
-					ci = int(f)
-				}
+			if ciVal, ok := jsonInt(val["ci"]); ok {
+				ci = ciVal
 			}
 			return NewVariablePointerValue(name.(string), ci), nil
 		}
 
+		if text, ok := val["#"].(string); ok {
+			return NewTag(text), nil
+		}
+
 		if obj, err := s.parseStateList(val); obj != nil || err != nil {
 			return obj, err
 		}
@@ -325,13 +376,16 @@ func (s *Story) parseStateList(val map[string]interface{}) (RuntimeObject, error
 
 	if originsVal, ok := val["origins"]; ok {
 		if originsList, ok := originsVal.([]interface{}); ok {
+			names := make([]string, 0, len(originsList))
 			for _, o := range originsList {
 				if name, ok := o.(string); ok {
+					names = append(names, name)
 					if def, ok := s.ListDefinitions.Lists[name]; ok {
 						inkList.Origins = append(inkList.Origins, def)
 					}
 				}
 			}
+			inkList.SetInitialOriginNames(names)
 		}
 	}
 
@@ -341,10 +395,7 @@ func (s *Story) parseStateList(val map[string]interface{}) (RuntimeObject, error
 func (s *Story) parseStateChoice(val map[string]interface{}) RuntimeObject {
 	if pathOnChoice, ok := val["*"]; ok {
 		path := pathOnChoice.(string)
-		flg := 0
-		if f, ok := val["flg"]; ok {
-			flg = int(f.(float64))
-		}
+		flg, _ := jsonInt(val["flg"])
 		cp := NewChoicePoint(false, false, false, false, false)
 		cp.SetPathStringOnChoice(path)
 		cp.SetFlags(flg)
@@ -352,10 +403,7 @@ func (s *Story) parseStateChoice(val map[string]interface{}) RuntimeObject {
 	}
 	if pathOnChoice, ok := val["+"]; ok {
 		path := pathOnChoice.(string)
-		flg := 0
-		if f, ok := val["flg"]; ok {
-			flg = int(f.(float64))
-		}
+		flg, _ := jsonInt(val["flg"])
 		cp := NewChoicePoint(false, false, false, false, false)
 		cp.SetPathStringOnChoice(path)
 		cp.SetFlags(flg)
@@ -379,6 +427,9 @@ func (s *Story) parseStateString(val string) (RuntimeObject, error) {
 	if val == "<>" {
 		return NewGlue(), nil
 	}
+	if val == VoidName {
+		return NewVoid(), nil
+	}
 
 	for i, name := range controlCommandNames {
 		if name == val {
@@ -393,11 +444,10 @@ func (s *Story) parseStateString(val string) (RuntimeObject, error) {
 }
 
 func (s *Story) parseStateListItem(inkList *List, key string, v interface{}) {
-	itemValFloat, ok := v.(float64)
+	itemVal, ok := jsonInt(v)
 	if !ok {
 		return
 	}
-	itemVal := int(itemValFloat)
 
 	parts := strings.Split(key, ".")
 	var originName, itemName string
@@ -420,3 +470,27 @@ func (s *Story) parseStateListItem(inkList *List, key string, v interface{}) {
 
 	inkList.Add(item, itemVal)
 }
+
+// flowDidEnd guesses whether a loaded flow had reached an END. Saves don't
+// record this, but ForceEnd leaves a flow with a single empty call stack
+// element and no previous content, while a DONE keeps the previous pointer.
+func flowDidEnd(flow *Flow) bool {
+	cs := flow.CallStack
+	if len(cs.Threads) != 1 || len(cs.Threads[0].CallStack) != 1 || len(flow.CurrentChoices) > 0 {
+		return false
+	}
+	thread := cs.Threads[0]
+	return thread.CallStack[0].CurrentPointer.IsNull() && thread.PreviousPointer.IsNull()
+}
+
+// jsonInt returns a decoded JSON number as an int.
+func jsonInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
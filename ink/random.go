@@ -0,0 +1,78 @@
+package ink
+
+// random is a port of the seeded System.Random generator from .NET (Knuth's
+// subtractive method). The reference runtime seeds a new instance for every
+// RANDOM, LIST_RANDOM and shuffle, so using the same algorithm gives the same
+// playthrough for the same story seed on every runtime.
+type random struct {
+	seedArray [56]int32
+	inext     int
+	inextp    int
+}
+
+const (
+	randomMBig  int32 = 2147483647
+	randomMSeed int32 = 161803398
+)
+
+// newRandom creates a generator seeded in the same way as new Random(seed).
+func newRandom(seed int32) *random {
+	r := &random{}
+
+	subtraction := seed
+	switch {
+	case seed == -2147483648:
+		subtraction = randomMBig
+	case seed < 0:
+		subtraction = -seed
+	}
+
+	mj := randomMSeed - subtraction
+	r.seedArray[55] = mj
+	mk := int32(1)
+	for i := 1; i < 55; i++ {
+		ii := (21 * i) % 55
+		r.seedArray[ii] = mk
+		mk = mj - mk
+		if mk < 0 {
+			mk += randomMBig
+		}
+		mj = r.seedArray[ii]
+	}
+	for k := 1; k < 5; k++ {
+		for i := 1; i < 56; i++ {
+			r.seedArray[i] -= r.seedArray[1+(i+30)%55]
+			if r.seedArray[i] < 0 {
+				r.seedArray[i] += randomMBig
+			}
+		}
+	}
+	r.inext = 0
+	r.inextp = 21
+	return r
+}
+
+// Next returns a non-negative pseudo-random number less than math.MaxInt32.
+func (r *random) Next() int {
+	locINext := r.inext + 1
+	if locINext >= 56 {
+		locINext = 1
+	}
+	locINextp := r.inextp + 1
+	if locINextp >= 56 {
+		locINextp = 1
+	}
+
+	retVal := r.seedArray[locINext] - r.seedArray[locINextp]
+	if retVal == randomMBig {
+		retVal--
+	}
+	if retVal < 0 {
+		retVal += randomMBig
+	}
+
+	r.seedArray[locINext] = retVal
+	r.inext = locINext
+	r.inextp = locINextp
+	return int(retVal)
+}
@@ -0,0 +1,82 @@
+package ink
+
+import "testing"
+
+func TestRandomMatchesReferenceSequence(t *testing.T) {
+	// First values of new System.Random(seed).Next() in .NET
+	cases := []struct {
+		Seed     int32
+		Expected int
+	}{
+		{Seed: 0, Expected: 1559595546},
+		{Seed: 1, Expected: 534011718},
+	}
+	for _, tc := range cases {
+		if got := newRandom(tc.Seed).Next(); got != tc.Expected {
+			t.Errorf("newRandom(%d).Next() = %d, want %d", tc.Seed, got, tc.Expected)
+		}
+	}
+}
+
+func TestListRandom(t *testing.T) {
+	// ~ SEED_RANDOM(10)
+	// {LIST_RANDOM((a, b, c))}
+	jsonStr := `{"root": [["ev", 10, "srnd", "pop", {"list": {"L.a": 1, "L.b": 2, "L.c": 3}}, "lrnd", "out", "/ev", "\n", "done"], "done", null],
+		"listDefs": {"L": {"a": 1, "b": 2, "c": 3}}, "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	text, err := story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	// new Random(10).Next() is 2041175501, which picks index 2
+	if text != "c\n" {
+		t.Errorf("Expected %q, got %q", "c\n", text)
+	}
+}
+
+func TestRandomWithInvalidRangeKeepsStackBalanced(t *testing.T) {
+	// {RANDOM(5, 1)} {RANDOM(-2147483648, 2147483647)}, then SEED_RANDOM
+	// and LIST_RANDOM with invalid arguments, each discarding its result
+	// above a value that must still be there to output.
+	jsonStr := `{"root": [["ev", 5, 1, "rnd", "out", "/ev", "^ ", "ev", -2147483648, 2147483647, "rnd", "out", "/ev", "^ ", "ev", 9, 1.5, "srnd", "pop", "out", "/ev", "^ ", "ev", 7, 3, "lrnd", "pop", "out", "/ev", "\n", "done"], "done", null], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	text, err := story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "0 0 9 7\n" {
+		t.Errorf("Expected %q, got %q", "0 0 9 7\n", text)
+	}
+	if errs := story.State().GetCurrentErrors(); len(errs) != 4 {
+		t.Errorf("Expected 4 errors, got %v", errs)
+	}
+}
+
+func TestShuffleIndexWithInvalidCountKeepsStackBalanced(t *testing.T) {
+	// The sequence count and an invalid element count are both popped,
+	// leaving 9 to be output once the result is discarded
+	jsonStr := `{"root": [["ev", 9, 0, 1.5, "seq", "pop", "out", "/ev", "\n", "done"], "done", null], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	text, err := story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "9\n" {
+		t.Errorf("Expected %q, got %q", "9\n", text)
+	}
+	if errs := story.State().GetCurrentErrors(); len(errs) != 1 {
+		t.Errorf("Expected 1 error, got %v", errs)
+	}
+}
@@ -1,11 +1,19 @@
 package ink
 
+import "slices"
+
 // StatePatch is used to apply changes to the state.
 type StatePatch struct {
 	Globals          map[string]RuntimeObject
 	ChangedVariables map[string]struct{} // Set
 	VisitCounts      map[*Container]int
 	TurnIndices      map[*Container]int
+
+	// visitCountOrder and turnIndexOrder list the containers in the order
+	// they were first added, so that applying the patch adds new containers
+	// to the state in that order.
+	visitCountOrder []*Container
+	turnIndexOrder  []*Container
 }
 
 // NewStatePatch creates a new StatePatch.
@@ -30,10 +38,28 @@ func NewStatePatch(toCopy *StatePatch) *StatePatch {
 		for k, v := range toCopy.TurnIndices {
 			sp.TurnIndices[k] = v
 		}
+		sp.visitCountOrder = slices.Clone(toCopy.visitCountOrder)
+		sp.turnIndexOrder = slices.Clone(toCopy.turnIndexOrder)
 	}
 	return sp
 }
 
+// SetVisitCount records a changed visit count.
+func (sp *StatePatch) SetVisitCount(container *Container, count int) {
+	if _, ok := sp.VisitCounts[container]; !ok {
+		sp.visitCountOrder = append(sp.visitCountOrder, container)
+	}
+	sp.VisitCounts[container] = count
+}
+
+// SetTurnIndex records a changed turn index.
+func (sp *StatePatch) SetTurnIndex(container *Container, index int) {
+	if _, ok := sp.TurnIndices[container]; !ok {
+		sp.turnIndexOrder = append(sp.turnIndexOrder, container)
+	}
+	sp.TurnIndices[container] = index
+}
+
 // GetGlobals returns the global variables in the patch.
 func (sp *StatePatch) GetGlobals() map[string]RuntimeObject {
 	return sp.Globals
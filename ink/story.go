@@ -3,6 +3,7 @@ package ink
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
 )
 
@@ -11,7 +12,25 @@ type Story struct {
 	MainContent       *Container
 	state             *StoryState
 	ListDefinitions   *ListDefinitionsOrigin
-	externalFunctions map[string]ExternalFunction
+	externalFunctions map[string]*externalFunctionDef
+
+	// AllowExternalFunctionFallbacks makes a call to an unbound EXTERNAL
+	// function run the ink function of the same name instead, so a story can
+	// be played without the game that provides the bindings.
+	AllowExternalFunctionFallbacks bool
+
+	variableObservers    map[string][]VariableObserver
+	allVariableObservers []VariableObserver
+
+	// stateSnapshotAtLastNewline is the state at the end of the current
+	// line, kept while the story looks ahead to see whether glue or more
+	// text on the next line joins onto it.
+	stateSnapshotAtLastNewline             *StoryState
+	sawLookaheadUnsafeFunctionAfterNewline bool
+
+	// recursiveContinueCount is the number of Continues in progress, which
+	// is more than one when ink calls the game, which calls back into ink.
+	recursiveContinueCount int
 }
 
 // ExternalFunction represents a bound external function.
@@ -55,10 +74,11 @@ func NewStory(jsonString string) (*Story, error) {
 	story := &Story{
 		MainContent:       rootContainer,
 		ListDefinitions:   listDefsOrigin,
-		externalFunctions: make(map[string]ExternalFunction),
+		externalFunctions: make(map[string]*externalFunctionDef),
 	}
 
 	story.state = NewStoryState(story)
+	story.state.GetVariablesState().variableChangedEvent = story.variableStateDidChange
 
 	err = story.ResetGlobals()
 	if err != nil {
@@ -81,6 +101,7 @@ func (s *Story) ResetGlobals() error {
 		}
 	}
 	s.state.GoToStart()
+	s.state.VariablesState.SnapshotDefaultGlobals()
 	return nil
 }
 
@@ -117,81 +138,79 @@ func (s *Story) ContinueMaximally() (string, error) {
 }
 
 // CurrentText returns the current output text.
-// CurrentText returns the current output text.
-//
-//nolint:gocognit
+// Text inside tags is not part of the output text; see CurrentTags.
 func (s *Story) CurrentText() string {
-	var sb strings.Builder
-	glueActive := false
+	return s.state.CurrentText()
+}
+
+// CurrentTags returns the tags attached to the line of content most recently
+// produced by Continue.
+func (s *Story) CurrentTags() []string {
+	return s.state.GetCurrentTags()
+}
 
-	for _, obj := range s.state.GetOutputStream() {
-		var txt string
-		isNewline := false
-		isInlineWhitespace := false
-		isGlue := false
+// GlobalTags returns the tags at the very top of the story, typically used
+// for metadata such as the title and author.
+func (s *Story) GlobalTags() ([]string, error) {
+	return s.tagsAtStartOfFlowContainer(s.MainContent)
+}
 
-		if _, ok := obj.(*Glue); ok {
-			isGlue = true
-		} else {
-			switch v := obj.(type) {
-			case *StringValue:
-				txt = v.Value
-				isNewline = v.isNewline
-				isInlineWhitespace = v.isInlineWhitespace
-			case *IntValue:
-				txt = fmt.Sprintf("%d", v.Value)
-			case *FloatValue:
-				txt = fmt.Sprintf("%v", v.Value)
-			case *ListValue:
-				val, _ := v.Cast(ValueTypeString)
-				if sv, ok := val.(*StringValue); ok {
-					txt = sv.Value
-				}
-			case *BoolValue:
-				txt = v.String()
-			case *Void:
-				continue
-			default:
-				continue
-			}
+// TagsForContentAtPath returns the tags at the start of the knot or stitch
+// at the given path, without evaluating any content or changing the state.
+func (s *Story) TagsForContentAtPath(path string) ([]string, error) {
+	pointer := s.PointerAtPath(NewPathFromString(path))
+	if pointer.IsNull() {
+		return nil, fmt.Errorf("path not found: %s", path)
+	}
+	return s.tagsAtStartOfFlowContainer(pointer.Container)
+}
+
+// tagsAtStartOfFlowContainer gathers the tags that appear before any other
+// content in a story, knot or stitch container. Only plain text tags are
+// supported, since dynamic tag content can only be evaluated by Continue.
+func (s *Story) tagsAtStartOfFlowContainer(flowContainer *Container) ([]string, error) {
+	// The first content of a flow container may be a nested (weave) container
+	for len(flowContainer.Content) > 0 {
+		first, ok := flowContainer.Content[0].(*Container)
+		if !ok {
+			break
 		}
+		flowContainer = first
+	}
 
-		if isGlue {
-			// Glue logic: Remove immediately preceding newline from the buffer
-			str := sb.String()
-			lastNonWS := -1
-			for i := len(str) - 1; i >= 0; i-- {
-				c := str[i]
-				if c != ' ' && c != '\t' && c != '\n' {
-					lastNonWS = i
-					break
-				}
+	var tags []string
+	inTag := false
+	for _, obj := range flowContainer.Content {
+		// Other control commands, such as an empty ev//ev pair, are skipped
+		if cmd, ok := obj.(*ControlCommand); ok {
+			switch cmd.CommandType {
+			case CommandTypeBeginTag:
+				inTag = true
+			case CommandTypeEndTag:
+				inTag = false
 			}
+			continue
+		}
 
-			if lastNonWS < len(str)-1 {
-				suffix := str[lastNonWS+1:]
-				if strings.ContainsRune(suffix, '\n') {
-					newSuffix := strings.ReplaceAll(suffix, "\n", "")
-					sb.Reset()
-					sb.WriteString(str[:lastNonWS+1])
-					sb.WriteString(newSuffix)
-				}
-			}
-			glueActive = true
-		} else {
-			if isNewline {
-				if glueActive {
-					continue
-				}
-			} else {
-				if !isInlineWhitespace {
-					glueActive = false
-				}
+		if inTag {
+			str, ok := obj.(*StringValue)
+			if !ok {
+				return nil, fmt.Errorf("tag contained non-text content; only plain text is allowed when using GlobalTags or TagsForContentAtPath, use Continue to evaluate dynamic tags")
 			}
-			sb.WriteString(txt)
+			tags = append(tags, str.Value)
+			continue
 		}
+
+		if tag, ok := obj.(*Tag); ok {
+			tags = append(tags, tag.Text)
+			continue
+		}
+
+		// Any other content ends the leading tags
+		break
 	}
-	return sb.String()
+
+	return tags, nil
 }
 
 // --- Internal Story Logic ---
@@ -201,31 +220,191 @@ func (s *Story) continueInternal(millisecsLimitAsync float64) error {
 	// Ensure root has path? GetPath initializes it if nil.
 	s.MainContent.GetPath()
 
+	s.state.DidSafeExit = false
 	s.state.ResetOutput()
 
+	s.recursiveContinueCount++
+	defer func() { s.recursiveContinueCount-- }()
+
+	// It's possible for ink to call game to call ink to call game etc. In
+	// this case, only batch observe variable changes for the outermost call.
+	if s.recursiveContinueCount == 1 {
+		variablesState := s.state.GetVariablesState()
+		variablesState.StartVariableObservation()
+		defer variablesState.CompleteVariableObservation()
+	}
+
 	// Step loop
 	for s.canContinueInternal() {
-		err := s.step()
+		outputStreamEndsInNewline, err := s.continueSingleStep()
 		if err != nil {
+			s.finishContinue()
 			return err
 		}
-		if s.state.OutputStreamEndsInNewline() {
+		if outputStreamEndsInNewline {
 			break
 		}
 	}
 
-	// Move generated choices to current choices
-	if len(s.state.GeneratedChoices) > 0 {
-		s.state.CurrentChoices = append(s.state.CurrentChoices, s.state.GeneratedChoices...)
-		if s.state.CurrentFlow != nil {
-			s.state.CurrentFlow.CurrentChoices = append(s.state.CurrentFlow.CurrentChoices, s.state.GeneratedChoices...)
-		}
-		s.state.GeneratedChoices = make([]*Choice, 0)
+	s.finishContinue()
+
+	// Finished a section of content without reaching a choice point?
+	if !s.canContinueInternal() {
+		s.warnIfUnexpectedEndOfContent()
 	}
 
 	return nil
 }
 
+// finishContinue rewinds any lookahead past the end of the line.
+func (s *Story) finishContinue() {
+	if s.stateSnapshotAtLastNewline != nil {
+		s.restoreStateSnapshot()
+	}
+	s.sawLookaheadUnsafeFunctionAfterNewline = false
+}
+
+// continueSingleStep runs one step of the story and returns true once a
+// complete line of output is ready.
+//
+// Once the output ends in a newline the line looks complete, but glue or a
+// function call further on may still join more text onto it. So the state
+// is snapshotted and evaluation carries on ahead: if more text is output
+// after the newline, the story rewinds to the snapshot and the line is done,
+// but if the newline is removed by glue, the lookahead is kept.
+func (s *Story) continueSingleStep() (bool, error) {
+	if err := s.step(); err != nil {
+		return false, err
+	}
+
+	// Run out of content and we have a default invisible choice that we can follow?
+	if !s.canContinueInternal() && s.state.GetCallStack().CurrentElement().Type != PushPopTypeFunctionEvaluationFromGame {
+		s.tryFollowDefaultInvisibleChoice()
+	}
+
+	// Don't save or rewind while generating a string, e.g. for choice text
+	if s.state.InStringEvaluation() {
+		return false, nil
+	}
+
+	// Did we look ahead too far?
+	if s.stateSnapshotAtLastNewline != nil {
+		change := calculateNewlineOutputStateChange(
+			s.stateSnapshotAtLastNewline.CurrentText(), s.state.CurrentText(),
+			len(s.stateSnapshotAtLastNewline.GetCurrentTags()), len(s.state.GetCurrentTags()))
+
+		// The last time we saw a newline it was definitely the end of
+		// the line, so rewind to that point
+		if change == outputStateExtendedBeyondNewline || s.sawLookaheadUnsafeFunctionAfterNewline {
+			s.restoreStateSnapshot()
+			return true, nil
+		}
+
+		// Glue removed the newline, so this is no longer the end of the line
+		if change == outputStateNewlineRemoved {
+			s.discardSnapshot()
+		}
+	}
+
+	// The current content ends in a newline, so look ahead to check it's
+	// really the end of the line
+	if s.state.OutputStreamEndsInNewline() {
+		if s.canContinueInternal() {
+			if s.stateSnapshotAtLastNewline == nil {
+				s.stateSnapshot()
+			}
+		} else {
+			s.discardSnapshot()
+		}
+	}
+
+	return false, nil
+}
+
+// outputStateChange describes how the output changed since the newline the
+// story is looking ahead from.
+type outputStateChange int
+
+const (
+	outputStateNoChange outputStateChange = iota
+	outputStateExtendedBeyondNewline
+	outputStateNewlineRemoved
+)
+
+func calculateNewlineOutputStateChange(prevText, currText string, prevTagCount, currTagCount int) outputStateChange {
+	// Simple case: nothing's changed, and we still have a newline at the end
+	// of the current content
+	newlineStillExists := len(currText) >= len(prevText) && len(prevText) > 0 && currText[len(prevText)-1] == '\n'
+	if prevTagCount == currTagCount && len(prevText) == len(currText) && newlineStillExists {
+		return outputStateNoChange
+	}
+
+	// Old newline has been removed, it wasn't the end of the line after all
+	if !newlineStillExists {
+		return outputStateNewlineRemoved
+	}
+
+	// Tag added - definitely the start of a new line
+	if currTagCount > prevTagCount {
+		return outputStateExtendedBeyondNewline
+	}
+
+	// There must be new content, check whether it's just whitespace
+	for _, c := range currText[len(prevText):] {
+		if c != ' ' && c != '\t' {
+			return outputStateExtendedBeyondNewline
+		}
+	}
+
+	// There's new text but it's just spaces and tabs, so there's still the
+	// potential for glue to kill the newline
+	return outputStateNoChange
+}
+
+// stateSnapshot keeps the current state and carries on with a patched copy.
+func (s *Story) stateSnapshot() {
+	s.stateSnapshotAtLastNewline = s.state
+	s.state = s.state.CopyAndStartPatching()
+}
+
+// restoreStateSnapshot rewinds to the state kept by stateSnapshot.
+func (s *Story) restoreStateSnapshot() {
+	s.stateSnapshotAtLastNewline.RestoreAfterPatch()
+	s.state = s.stateSnapshotAtLastNewline
+	s.stateSnapshotAtLastNewline = nil
+	s.state.ApplyAnyPatch()
+}
+
+// discardSnapshot commits the changes made since stateSnapshot.
+func (s *Story) discardSnapshot() {
+	s.state.ApplyAnyPatch()
+	s.stateSnapshotAtLastNewline = nil
+}
+
+// warnIfUnexpectedEndOfContent adds a warning when the story ran out of
+// content without offering choices or reaching a DONE or END. A thread left
+// on the call stack is an error, since threads should have been popped.
+func (s *Story) warnIfUnexpectedEndOfContent() {
+	callStack := s.state.GetCallStack()
+	if callStack.CanPopThread() {
+		s.state.AddError("Thread available to pop, threads should always be flat by the end of evaluation?")
+	}
+	if len(s.state.GetGeneratedChoices()) > 0 || s.state.DidSafeExit {
+		return
+	}
+
+	switch {
+	case callStack.CanPopType(PushPopTypeTunnel):
+		s.state.AddWarning("unexpectedly reached end of content. Do you need a '->->' to return from a tunnel?")
+	case callStack.CanPopType(PushPopTypeFunction):
+		s.state.AddWarning("unexpectedly reached end of content. Do you need a '~ return'?")
+	case !callStack.CanPop():
+		s.state.AddWarning("ran out of content. Do you need a '-> DONE' or '-> END'?")
+	default:
+		s.state.AddWarning("unexpectedly reached end of content for unknown reason. Please debug compiler!")
+	}
+}
+
 // CanContinueInternal checks if the story logic can continue stepping.
 func (s *Story) canContinueInternal() bool {
 	return !s.state.GetCurrentPointer().IsNull()
@@ -234,7 +413,20 @@ func (s *Story) canContinueInternal() bool {
 // CanContinue checks if the story has more content to yield immediately.
 // If true, the user can call Continue().
 func (s *Story) CanContinue() bool {
-	return s.canContinueInternal() && len(s.state.CurrentChoices) == 0
+	return s.canContinueInternal() && len(s.state.GetGeneratedChoices()) == 0
+}
+
+// HasEnded reports whether the current flow has reached an END. Unlike pausing at a
+// DONE or waiting for a choice, there is nothing left to continue or choose,
+// although ChoosePathString can still jump to another part of the story.
+//
+// Saves don't record whether a flow ended, so after LoadState this is a guess
+// from the shape of the call stack: a single thread with a single element,
+// no current or previous content and no choices, which is what an END
+// leaves. Any other state of that shape, such as one from a hand-edited
+// save, is also reported as ended.
+func (s *Story) HasEnded() bool {
+	return s.state.CurrentFlow.DidEnd
 }
 
 func (s *Story) processChoice(choicePoint *ChoicePoint) *Choice {
@@ -250,22 +442,24 @@ func (s *Story) processChoice(choicePoint *ChoicePoint) *Choice {
 
 	startText := ""
 	choiceOnlyText := ""
-	// TODO: Implement support for choice tags.
+	var tags []string
 
-	// 2. Choice Only Content (Pop from stack)
 	if choicePoint.HasChoiceOnlyContent {
-		obj := s.state.PopEvaluationStack()
-		if strVal, ok := obj.(*StringValue); ok {
-			choiceOnlyText = strVal.Value
-		}
-		// TODO: Handle tags which might be popped too?
+		choiceOnlyText = s.popChoiceStringAndTags(&tags)
 	}
 
-	// 3. Start Content
 	if choicePoint.HasStartContent {
-		obj := s.state.PopEvaluationStack()
-		if strVal, ok := obj.(*StringValue); ok {
-			startText = strVal.Value
+		startText = s.popChoiceStringAndTags(&tags)
+	}
+
+	// Don't create choice if it's a once-only choice that has already been chosen
+	cpPath := choicePoint.GetPath()
+	relPath := NewPathFromString(choicePoint.PathStringOnChoice)
+	if choicePoint.OnceOnly {
+		if target, ok := s.resolvePath(choicePoint, relPath).(*Container); ok {
+			if s.state.VisitCountForContainer(target) > 0 {
+				showChoice = false
+			}
 		}
 	}
 
@@ -273,34 +467,134 @@ func (s *Story) processChoice(choicePoint *ChoicePoint) *Choice {
 		return nil
 	}
 
-	text := startText + choiceOnlyText
-
 	choice := NewChoice()
-	choice.Text = text
+	choice.Text = strings.Trim(startText+choiceOnlyText, " \t")
+	choice.Tags = tags
 	choice.SetPathStringOnChoice(choicePoint.PathStringOnChoice)
 
 	// Resolve Absolute Path for Target
 	// ChoicePoint path is the base.
 	// TargetPath string is relative to it (usually).
-	cpPath := choicePoint.GetPath()
-	relPath := NewPathFromString(choicePoint.PathStringOnChoice)
 	choice.TargetPath = cpPath.PathByAppendingPath(relPath)
 
-	choice.Index = len(s.state.GeneratedChoices)
+	choice.Index = len(s.state.GetGeneratedChoices())
 	choice.SourcePath = cpPath.String()
 
-	// Flags
-	choice.OriginalThreadIndex = len(s.state.GetCallStack().Threads) - 1
+	// Keep a copy of the generating thread, so that choosing the choice can
+	// resume with its call stack and temporaries.
+	choice.ThreadAtGeneration = s.state.GetCallStack().ForkThread()
+	choice.OriginalThreadIndex = choice.ThreadAtGeneration.ThreadIndex
 	choice.IsInvisibleDefault = choicePoint.IsInvisibleDefault
 
-	s.state.GeneratedChoices = append(s.state.GeneratedChoices, choice)
+	s.state.AddGeneratedChoice(choice)
 
 	return choice
 }
 
-// GetCurrentChoices returns the list of current choices.
+// popChoiceStringAndTags pops a string built for choice text off the
+// evaluation stack, along with any tags that were generated with it.
+func (s *Story) popChoiceStringAndTags(tags *[]string) string {
+	text := ""
+	if strVal, ok := s.state.PopEvaluationStack().(*StringValue); ok {
+		text = strVal.Value
+	}
+
+	for {
+		tag, ok := s.state.PeekEvaluationStack().(*Tag)
+		if !ok {
+			break
+		}
+		s.state.PopEvaluationStack()
+		// Popped in reverse order
+		*tags = append([]string{tag.Text}, *tags...)
+	}
+
+	return text
+}
+
+// GetCurrentChoices returns the list of choices available to the player.
+// Invisible default choices are never offered; they are taken automatically
+// once the story runs out of other content.
 func (s *Story) GetCurrentChoices() []*Choice {
-	return s.state.CurrentChoices
+	allChoices := s.state.GetGeneratedChoices()
+	choices := make([]*Choice, 0, len(allChoices))
+	for _, c := range allChoices {
+		if !c.IsInvisibleDefault {
+			c.Index = len(choices)
+			choices = append(choices, c)
+		}
+	}
+	return choices
+}
+
+// tryFollowDefaultInvisibleChoice diverts to the first invisible default
+// choice when no other choices were generated, returning whether it did.
+func (s *Story) tryFollowDefaultInvisibleChoice() bool {
+	allChoices := s.state.GetGeneratedChoices()
+
+	var invisibleChoices []*Choice
+	for _, c := range allChoices {
+		if c.IsInvisibleDefault {
+			invisibleChoices = append(invisibleChoices, c)
+		}
+	}
+	if len(invisibleChoices) == 0 || len(allChoices) > len(invisibleChoices) {
+		return false
+	}
+
+	choice := invisibleChoices[0]
+
+	if choice.ThreadAtGeneration != nil {
+		s.state.GetCallStack().SetCurrentThread(choice.ThreadAtGeneration)
+
+		// If there's a chance that this state will be rolled back to before
+		// the invisible choice then make sure that the choice thread is left
+		// intact, and it isn't re-entered in an old state.
+		if s.stateSnapshotAtLastNewline != nil {
+			s.state.GetCallStack().SetCurrentThread(s.state.GetCallStack().ForkThread())
+		}
+	}
+
+	// Invisible choices don't count as a turn
+	s.choosePath(choice.TargetPath, false)
+	return true
+}
+
+// SwitchFlow makes the named flow current, creating it if it doesn't exist
+// yet. Each flow has its own call stack, output and choices, so several
+// conversations can run in parallel. Global variables are shared.
+func (s *Story) SwitchFlow(flowName string) error {
+	if flowName == "" {
+		return fmt.Errorf("flow name must not be empty")
+	}
+	s.state.SwitchFlow(flowName)
+	return nil
+}
+
+// SwitchToDefaultFlow makes the story's default flow current.
+func (s *Story) SwitchToDefaultFlow() {
+	s.state.SwitchToDefaultFlow()
+}
+
+// RemoveFlow destroys the named flow, switching back to the default flow if
+// it is current. The default flow can't be removed.
+func (s *Story) RemoveFlow(flowName string) error {
+	return s.state.RemoveFlow(flowName)
+}
+
+// CurrentFlowName returns the name of the current flow.
+func (s *Story) CurrentFlowName() string {
+	return s.state.CurrentFlow.Name
+}
+
+// CurrentFlowIsDefaultFlow reports whether the default flow is current.
+func (s *Story) CurrentFlowIsDefaultFlow() bool {
+	return s.state.CurrentFlow.Name == DefaultFlowName
+}
+
+// AliveFlowNames returns the names of all flows other than the default flow.
+func (s *Story) AliveFlowNames() []string {
+	return s.state.AliveFlowNames()
 }
 
 // ChoosePathString moves the instruction pointer to the path given by the string.
@@ -310,30 +604,44 @@ func (s *Story) ChoosePathString(path string) error {
 	if pointer.IsNull() {
 		return fmt.Errorf("path not found: %s", path)
 	}
+	s.setChosenPointer(pointer, true)
+	return nil
+}
+
+// choosePath diverts to the given path, clearing the current choices.
+func (s *Story) choosePath(path *Path, incrementingTurnIndex bool) {
+	s.setChosenPointer(s.PointerAtPath(path), incrementingTurnIndex)
+}
+
+func (s *Story) setChosenPointer(pointer Pointer, incrementingTurnIndex bool) {
 	s.state.SetCurrentPointer(pointer)
-	s.state.CurrentChoices = make([]*Choice, 0)
-	if s.state.CurrentFlow != nil {
-		s.state.CurrentFlow.CurrentChoices = make([]*Choice, 0)
+	s.state.CurrentFlow.DidEnd = false
+	s.state.CurrentFlow.CurrentChoices = make([]*Choice, 0)
+	if incrementingTurnIndex {
+		s.state.CurrentTurnIndex++
 	}
-	return nil
+	s.visitChangedContainersDueToDivert()
 }
 
 // ChooseChoiceIndex chooses a choice by its index.
 func (s *Story) ChooseChoiceIndex(index int) error {
-	if index < 0 || index >= len(s.state.CurrentChoices) {
+	choices := s.GetCurrentChoices()
+	if index < 0 || index >= len(choices) {
 		return fmt.Errorf("choice out of range")
 	}
 
-	choice := s.state.CurrentChoices[index]
+	choice := choices[index]
 
-	// Allow thread jumping etc (Simplified for now)
+	// Replace the call stack with the one from the thread at the choosing
+	// point, so that we jump into the right place in the flow. A flow forked
+	// by a new thread can have several leading edges, each with its own
+	// context.
+	if choice.ThreadAtGeneration != nil {
+		s.state.GetCallStack().SetCurrentThread(choice.ThreadAtGeneration)
+	}
 
 	// Divert
-	s.state.SetCurrentPointer(s.PointerAtPath(choice.TargetPath))
-	s.state.CurrentChoices = make([]*Choice, 0)
-	if s.state.CurrentFlow != nil {
-		s.state.CurrentFlow.CurrentChoices = make([]*Choice, 0)
-	}
+	s.choosePath(choice.TargetPath, true)
 
 	return nil
 }
@@ -361,7 +669,7 @@ func (s *Story) step() error {
 		}
 
 		// Mark container as being entered
-		s.state.IncrementVisitCountForContainer(container)
+		s.visitContainer(container, true)
 
 		// No content? the most we can do is step past it
 		if len(container.Content) == 0 {
@@ -410,7 +718,14 @@ func (s *Story) step() error {
 
 	// Content to add to evaluation stack or the output stream
 	if shouldAddToStream {
-		// TODO: VariablePointerValue context fix
+		// A variable pointer without a context is a reference to a variable
+		// in the current scope, so work out whether that's a temporary or a
+		// global now, while that scope is still current
+		if varPtr, ok := currentContentObj.(*VariablePointerValue); ok && varPtr.ContextIndex() == -1 {
+			// Create new object so we're not overwriting the story's own data
+			contextIdx := s.state.GetCallStack().ContextForVariableNamed(varPtr.VariableName())
+			currentContentObj = NewVariablePointerValue(varPtr.VariableName(), contextIdx)
+		}
 
 		// Expression evaluation content
 		if s.state.GetInExpressionEvaluation() {
@@ -427,7 +742,12 @@ func (s *Story) step() error {
 		return err
 	}
 
-	// TODO: StartThread command check
+	// Starting a thread should be done after the increment to the content
+	// pointer, so that when returning from the thread, it returns to the
+	// content after this instruction.
+	if cmd, ok := currentContentObj.(*ControlCommand); ok && cmd.CommandType == CommandTypeStartThread {
+		s.state.GetCallStack().PushThread()
+	}
 
 	return nil
 }
@@ -442,7 +762,7 @@ func (s *Story) NextContent() error {
 		s.state.SetCurrentPointer(s.state.GetDivertedPointer())
 		s.state.SetDivertedPointer(NullPointer)
 
-		// TODO: visitChangedContainersDueToDivert()
+		s.visitChangedContainersDueToDivert()
 
 		// Diverted location has valid content?
 		if !s.state.GetCurrentPointer().IsNull() {
@@ -470,13 +790,8 @@ func (s *Story) NextContent() error {
 				s.state.PushEvaluationStack(NewVoid())
 			}
 			didPop = true
-		case s.state.GetCallStack().CanPop():
-			// Auto-pop ANY other type (Tunnel, etc)
-			// We effectively finished the content of a container that was pushed to the stack
-			err := s.state.PopCallStack(s.state.GetCallStack().CurrentElement().Type)
-			if err != nil {
-				s.state.AddError(fmt.Sprintf("Failed to pop callstack: %v", err))
-			}
+		case s.state.GetCallStack().CanPopThread():
+			s.state.GetCallStack().PopThread()
 			didPop = true
 		default:
 			s.state.TryExitFunctionEvaluationFromGame()
@@ -491,6 +806,73 @@ func (s *Story) NextContent() error {
 	return nil
 }
 
+// visitContainer records a visit to the container, if the compiler marked it
+// for counting. Containers that count at start only are skipped unless they
+// are being entered from their first element.
+func (s *Story) visitContainer(container *Container, atStart bool) {
+	if container.CountingAtStartOnly && !atStart {
+		return
+	}
+	if container.VisitsShouldBeCounted {
+		s.state.IncrementVisitCountForContainer(container)
+	}
+	if container.TurnIndexShouldBeCounted {
+		s.state.RecordTurnIndexVisitToContainer(container)
+	}
+}
+
+// visitChangedContainersDueToDivert counts visits to the containers that were
+// entered by jumping to the current pointer, i.e. those that are ancestors of
+// the new content but weren't ancestors of the previous content.
+func (s *Story) visitChangedContainersDueToDivert() {
+	previousPointer := s.state.GetPreviousPointer()
+	pointer := s.state.GetCurrentPointer()
+
+	// Unless we're pointing directly at a piece of content, the main stepping
+	// function does the counting when it enters the container.
+	if pointer.IsNull() || pointer.Index == -1 {
+		return
+	}
+
+	// Find the previously open set of containers
+	prevContainers := make(map[*Container]bool)
+	if !previousPointer.IsNull() {
+		prevAncestor, ok := previousPointer.Resolve().(*Container)
+		if !ok {
+			prevAncestor = previousPointer.Container
+		}
+		for prevAncestor != nil {
+			prevContainers[prevAncestor] = true
+			prevAncestor, _ = prevAncestor.GetParent().(*Container)
+		}
+	}
+
+	// If the new object is a container itself, it will be visited when the next
+	// step drills into it, but its new ancestors still need counting.
+	currentChildOfContainer := pointer.Resolve()
+	if currentChildOfContainer == nil {
+		return
+	}
+	currentContainerAncestor, _ := currentChildOfContainer.GetParent().(*Container)
+
+	allChildrenEnteredAtStart := true
+	for currentContainerAncestor != nil && (!prevContainers[currentContainerAncestor] || currentContainerAncestor.CountingAtStartOnly) {
+		// Only count it as entering at the start if we're diverting directly to
+		// the first leaf node, not somewhere within a nested first child.
+		enteringAtStart := len(currentContainerAncestor.Content) > 0 &&
+			currentChildOfContainer == currentContainerAncestor.Content[0] &&
+			allChildrenEnteredAtStart
+		if !enteringAtStart {
+			allChildrenEnteredAtStart = false
+		}
+
+		s.visitContainer(currentContainerAncestor, enteringAtStart)
+
+		currentChildOfContainer = currentContainerAncestor
+		currentContainerAncestor, _ = currentContainerAncestor.GetParent().(*Container)
+	}
+}
+
 // IncrementContentPointer increments the execution pointer.
 func (s *Story) IncrementContentPointer() bool {
 	successfulIncrement := true
@@ -590,8 +972,6 @@ func (s *Story) PointerAtPath(path *Path) Pointer {
 
 	var currentObj RuntimeObject = s.MainContent
 
-	// fmt.Printf("DEBUG: Resolving Path: %s\n", path.String())
-
 	for _, component := range path.Components {
 		// If current object is a container, try to find child
 		container, isContainer := currentObj.(*Container)
@@ -617,13 +997,11 @@ func (s *Story) PointerAtPath(path *Path) Pointer {
 				}
 			}
 
-			fmt.Printf("DEBUG: PointerAtPath failed. CurrentObj is not container. Type: %T. Path: %s. Component: %s\n", currentObj, path.String(), component.String())
 			return NullPointer
 		}
 
 		child, err := container.ContentAtPathComponent(component)
 		if err != nil {
-			fmt.Printf("DEBUG: PointerAtPath failed at component '%s' (Path: %s). Error: %v. Keys in container: %v\n", component, path.String(), err, keys(container.NamedContent))
 			return NullPointer
 		}
 		currentObj = child
@@ -637,6 +1015,25 @@ func (s *Story) PointerAtPath(path *Path) Pointer {
 	return s.PointerAtContent(currentObj)
 }
 
+// resolvePath resolves a path found on a content object, such as a read count
+// reference. Relative paths are resolved from the object's container, where
+// the first "^" refers to that container itself.
+func (s *Story) resolvePath(obj RuntimeObject, path *Path) RuntimeObject {
+	if !path.IsRelative {
+		return s.MainContent.ContentAtPath(path)
+	}
+
+	nearestContainer, ok := obj.(*Container)
+	if !ok {
+		nearestContainer, ok = obj.GetParent().(*Container)
+		if !ok {
+			return nil
+		}
+		path = path.Tail()
+	}
+	return nearestContainer.ContentAtPath(path)
+}
+
 // PointerAtContent returns the pointer for a content object.
 func (s *Story) PointerAtContent(obj RuntimeObject) Pointer {
 	if obj == nil {
@@ -677,7 +1074,7 @@ func (s *Story) performControlCommand(evalCommand *ControlCommand) bool {
 		if len(s.state.EvaluationStack) > 0 {
 			output := s.state.PopEvaluationStack()
 			if _, isVoid := output.(*Void); !isVoid {
-				s.state.PushToOutputStream(output)
+				s.state.PushToOutputStream(NewStringValue(valueToOutputString(output)))
 			}
 		}
 		return true
@@ -690,59 +1087,403 @@ func (s *Story) performControlCommand(evalCommand *ControlCommand) bool {
 		s.state.PopEvaluationStack()
 		return true
 	case CommandTypePopFunction, CommandTypePopTunnel:
-		pushPopType := PushPopTypeFunction
+		popType := PushPopTypeFunction
+		var overrideTunnelReturnTarget *DivertTargetValue
 		if evalCommand.CommandType == CommandTypePopTunnel {
-			pushPopType = PushPopTypeTunnel
-
-			if len(s.state.EvaluationStack) > 0 {
-				peek := s.state.PeekEvaluationStack()
-				if divertVal, ok := peek.(*DivertTargetValue); ok {
-					s.state.PopEvaluationStack()
-					err := s.state.PopCallStack(pushPopType) // Ignore error?
-					if err != nil {
-						s.state.AddError(fmt.Sprintf("formatting error: %v", err))
-					}
-					s.state.SetDivertedPointer(s.PointerAtPath(divertVal.GetTargetPath()))
-					return true
-				}
+			popType = PushPopTypeTunnel
+
+			// Tunnel onwards is allowed to specify an optional override
+			// for where to return to
+			overrideTunnelReturnTarget, _ = s.state.PopEvaluationStack().(*DivertTargetValue)
+		}
+
+		if s.state.TryExitFunctionEvaluationFromGame() {
+			return true
+		}
+
+		callStack := s.state.GetCallStack()
+		if callStack.CurrentElement().Type != popType || !callStack.CanPop() {
+			names := map[PushPopType]string{
+				PushPopTypeFunction: "function return statement (~ return)",
+				PushPopTypeTunnel:   "tunnel onwards statement (->->)",
+			}
+			expected := names[callStack.CurrentElement().Type]
+			if !callStack.CanPop() {
+				expected = "end of flow (-> END or choice)"
 			}
+			s.state.AddError(fmt.Sprintf("Found %s, when expected %s", names[popType], expected))
+			return true
 		}
 
-		err := s.state.PopCallStack(pushPopType)
-		if err != nil {
+		if err := s.state.PopCallStack(popType); err != nil {
+			s.state.AddError(err.Error())
+			return true
+		}
+
+		// Does tunnel onwards override by diverting to a new ->-> target?
+		if overrideTunnelReturnTarget != nil {
+			s.state.SetDivertedPointer(s.PointerAtPath(overrideTunnelReturnTarget.GetTargetPath()))
+		}
+		return true
+	case CommandTypeBeginString:
+		// Text output from here to the EndString is captured into a string
+		// value, rather than going to the story output
+		if !s.state.GetInExpressionEvaluation() {
+			s.state.AddError("Expected to be in an expression when evaluating a string")
+		}
+		s.state.PushToOutputStream(evalCommand)
+		s.state.SetInExpressionEvaluation(false)
+		return true
+	case CommandTypeEndString:
+		s.endStringEvaluation()
+		return true
+	case CommandTypeTurns:
+		s.state.PushEvaluationStack(NewIntValue(s.state.CurrentTurnIndex + 1))
+		return true
+	case CommandTypeTurnsSince, CommandTypeReadCount:
+		s.performReadCountCommand(evalCommand.CommandType)
+		return true
+	case CommandTypeRandom:
+		s.performRandom()
+		return true
+	case CommandTypeSeedRandom:
+		seed, ok := s.state.PopEvaluationStack().(*IntValue)
+		if !ok {
+			s.state.AddError("Invalid value passed to SEED_RANDOM")
+			s.state.PushEvaluationStack(NewVoid())
+			return true
+		}
+		// Story seed affects both RANDOM and shuffle behaviour
+		s.state.StorySeed = seed.Value
+		s.state.PreviousRandom = 0
+		// SEED_RANDOM returns nothing
+		s.state.PushEvaluationStack(NewVoid())
+		return true
+	case CommandTypeListRandom:
+		s.performListRandom()
+		return true
+	case CommandTypeListFromInt:
+		s.performListFromInt()
+		return true
+	case CommandTypeListRange:
+		maxVal, maxOk := s.state.PopEvaluationStack().(Value)
+		minVal, minOk := s.state.PopEvaluationStack().(Value)
+		targetList, listOk := s.state.PopEvaluationStack().(*ListValue)
+		if !listOk || !minOk || !maxOk {
+			s.state.AddError("Expected list, minimum and maximum for LIST_RANGE")
+			s.state.PushEvaluationStack(NewListValue(NewList()))
 			return true
 		}
+		result := targetList.Value.ListWithSubRange(minVal.GetValueObject(), maxVal.GetValueObject())
+		s.state.PushEvaluationStack(NewListValue(result))
+		return true
+	case CommandTypeSequenceShuffleIndex:
+		s.state.PushEvaluationStack(NewIntValue(s.nextSequenceShuffleIndex()))
+		return true
+	case CommandTypeChoiceCount:
+		count := len(s.state.GetGeneratedChoices())
+		s.state.PushEvaluationStack(NewIntValue(count))
+		return true
+	case CommandTypeVisitIndex:
+		// Index rather than count, so the first visit is 0
+		count := s.state.VisitCountForContainer(s.state.GetCurrentPointer().Container) - 1
+		s.state.PushEvaluationStack(NewIntValue(count))
+		return true
+	case CommandTypeBeginTag:
+		s.state.PushToOutputStream(evalCommand)
+		return true
+	case CommandTypeEndTag:
+		// Tags inside string evaluation can only come from choice text. They are
+		// pushed to the evaluation stack to be picked up with the choice's text.
+		if s.state.InStringEvaluation() {
+			s.endChoiceTag()
+		} else {
+			s.state.PushToOutputStream(evalCommand)
+		}
 		return true
 	case CommandTypeStartThread:
-		s.state.InThreadGeneration = true
+		// Handled in step, after the content pointer has been incremented
 		return true
 	case CommandTypeDone:
+		// We may exist in the context of the initial act of creating the
+		// thread, or in the context of evaluating the content.
 		if s.state.GetCallStack().CanPopThread() {
 			s.state.GetCallStack().PopThread()
-			s.state.SetDivertedPointer(s.state.GetCurrentPointer())
 			return true
 		}
+		// In normal flow - allow safe exit without warning
+		s.state.DidSafeExit = true
+		// Stop flow in current thread
 		s.state.SetCurrentPointer(NullPointer)
 		return true
+	case CommandTypeEnd:
+		s.state.ForceEnd()
+		return true
 	}
 	return true
 }
 
-//nolint:gocognit
-func (s *Story) performDivert(divert *Divert) bool {
-	if s.state.InThreadGeneration {
-		if divert.PushesToStack {
-			s.state.CallStack.Push(divert.StackPushType, 0, 0)
+// nextRandom returns the next number in the story's random sequence. A fresh
+// generator is seeded from the story seed and the previous result each time,
+// so the sequence survives a save and load.
+func (s *Story) nextRandom() int {
+	resultSeed := int32(s.state.StorySeed + s.state.PreviousRandom)
+	next := newRandom(resultSeed).Next()
+	s.state.PreviousRandom = next
+	return next
+}
+
+// performRandom handles RANDOM(min, max), which is inclusive of both ends.
+// On an error, 0 is pushed in place of the result, so that whatever uses
+// the result still finds a value on the evaluation stack.
+func (s *Story) performRandom() {
+	maxInt, maxOk := s.state.PopEvaluationStack().(*IntValue)
+	minInt, minOk := s.state.PopEvaluationStack().(*IntValue)
+	if !minOk {
+		s.state.AddError("Invalid value for minimum parameter of RANDOM(min, max)")
+		s.state.PushEvaluationStack(NewIntValue(0))
+		return
+	}
+	if !maxOk {
+		s.state.AddError("Invalid value for maximum parameter of RANDOM(min, max)")
+		s.state.PushEvaluationStack(NewIntValue(0))
+		return
+	}
+
+	randomRange := int64(maxInt.Value) - int64(minInt.Value) + 1
+	if randomRange > math.MaxInt32 {
+		s.state.AddError("RANDOM was called with a range that exceeds the size that ink numbers can use.")
+		s.state.PushEvaluationStack(NewIntValue(0))
+		return
+	}
+	if randomRange <= 0 {
+		s.state.AddError(fmt.Sprintf("RANDOM was called with minimum as %d and maximum as %d. The maximum must be larger", minInt.Value, maxInt.Value))
+		s.state.PushEvaluationStack(NewIntValue(0))
+		return
+	}
+
+	chosenValue := s.nextRandom()%int(randomRange) + minInt.Value
+	s.state.PushEvaluationStack(NewIntValue(chosenValue))
+}
+
+// performListFromInt handles list item lookups by value, e.g. Colours(2).
+// On an error, an empty list is pushed in place of the result.
+func (s *Story) performListFromInt() {
+	intVal, intOk := s.state.PopEvaluationStack().(*IntValue)
+	listNameVal, nameOk := s.state.PopEvaluationStack().(*StringValue)
+	if !intOk {
+		s.state.AddError("Passed non-integer when creating a list element from a numerical value.")
+		s.state.PushEvaluationStack(NewListValue(NewList()))
+		return
+	}
+	if !nameOk {
+		s.state.AddError("Expected a list name when creating a list element from a numerical value.")
+		s.state.PushEvaluationStack(NewListValue(NewList()))
+		return
+	}
+
+	var def *ListDefinition
+	if s.ListDefinitions != nil {
+		def = s.ListDefinitions.Lists[listNameVal.Value]
+	}
+	if def == nil {
+		s.state.AddError("Failed to find LIST called " + listNameVal.Value)
+		s.state.PushEvaluationStack(NewListValue(NewList()))
+		return
+	}
+
+	generatedList := NewList()
+	if item, ok := def.ItemWithValue(intVal.Value); ok {
+		generatedList.Add(item, intVal.Value)
+	}
+	s.state.PushEvaluationStack(NewListValue(generatedList))
+}
+
+// nextSequenceShuffleIndex picks the element of a shuffle to show, given the
+// element count and the sequence's visit count on the evaluation stack. Every
+// pass through the full shuffle is a fixed permutation, seeded from the
+// sequence's path, the number of completed loops and the story seed.
+func (s *Story) nextSequenceShuffleIndex() int {
+	numElementsVal, numElementsOk := s.state.PopEvaluationStack().(*IntValue)
+	seqCountVal, seqCountOk := s.state.PopEvaluationStack().(*IntValue)
+	if !numElementsOk {
+		s.state.AddError("expected number of elements in sequence for shuffle index")
+		return 0
+	}
+	if !seqCountOk {
+		s.state.AddError("expected sequence count for shuffle index")
+		return 0
+	}
+
+	numElements := numElementsVal.Value
+	if numElements <= 0 {
+		return 0
+	}
+	loopIndex := seqCountVal.Value / numElements
+	iterationIndex := seqCountVal.Value % numElements
+
+	// The same shuffle is generated each time the runtime returns to the
+	// sequence, until it has looped around the whole thing
+	sequenceHash := 0
+	for _, c := range s.state.GetCurrentPointer().Container.GetPath().String() {
+		sequenceHash += int(c)
+	}
+	r := newRandom(int32(sequenceHash + loopIndex + s.state.StorySeed))
+
+	unpickedIndices := make([]int, numElements)
+	for i := range unpickedIndices {
+		unpickedIndices[i] = i
+	}
+
+	for i := 0; ; i++ {
+		chosen := r.Next() % len(unpickedIndices)
+		chosenIndex := unpickedIndices[chosen]
+		unpickedIndices = append(unpickedIndices[:chosen], unpickedIndices[chosen+1:]...)
+		if i == iterationIndex {
+			return chosenIndex
+		}
+	}
+}
+
+// performListRandom handles LIST_RANDOM, which picks a single item from a list.
+// On an error, an empty list is pushed in place of the result.
+func (s *Story) performListRandom() {
+	listVal, ok := s.state.PopEvaluationStack().(*ListValue)
+	if !ok {
+		s.state.AddError("Expected list for LIST_RANDOM")
+		s.state.PushEvaluationStack(NewListValue(NewList()))
+		return
+	}
+
+	newList := NewList()
+	items := listVal.Value.OrderedItems()
+	if len(items) > 0 {
+		randomItem := items[s.nextRandom()%len(items)]
+
+		// The origin is simply the origin of the one item
+		newList.Add(randomItem.Item, randomItem.Value)
+		if s.ListDefinitions != nil {
+			if def, ok := s.ListDefinitions.Lists[randomItem.Item.OriginName]; ok {
+				newList.Origins = append(newList.Origins, def)
+			}
+		}
+	}
+
+	s.state.PushEvaluationStack(NewListValue(newList))
+}
+
+// performReadCountCommand handles TURNS_SINCE and READ_COUNT, which both take
+// a divert target from the evaluation stack. Without a valid target, the
+// never-visited result is pushed: -1 for TURNS_SINCE and 0 for READ_COUNT.
+func (s *Story) performReadCountCommand(commandType CommandType) {
+	target := s.state.PopEvaluationStack()
+	divertTarget, ok := target.(*DivertTargetValue)
+	if !ok {
+		extraNote := ""
+		if _, isInt := target.(*IntValue); isInt {
+			extraNote = ". Did you accidentally pass a read count ('knot_name') instead of a target ('-> knot_name')?"
+		}
+		s.state.AddError(fmt.Sprintf("TURNS_SINCE / READ_COUNT expected a divert target (knot, stitch, label name), but saw %v%s", target, extraNote))
+		if commandType == CommandTypeTurnsSince {
+			s.state.PushEvaluationStack(NewIntValue(-1))
+		} else {
+			s.state.PushEvaluationStack(NewIntValue(0))
+		}
+		return
+	}
+
+	container, _ := s.MainContent.ContentAtPath(divertTarget.GetTargetPath()).(*Container)
+
+	var count int
+	switch {
+	case container != nil && commandType == CommandTypeTurnsSince:
+		count = s.state.TurnsSinceForContainer(container)
+	case container != nil:
+		count = s.state.VisitCountForContainer(container)
+	case commandType == CommandTypeTurnsSince:
+		count = -1 // Never visited
+		s.state.AddWarning(fmt.Sprintf("Failed to find container for TURNS_SINCE lookup at %s", divertTarget.GetTargetPath()))
+	default:
+		count = 0
+		s.state.AddWarning(fmt.Sprintf("Failed to find container for READ_COUNT lookup at %s", divertTarget.GetTargetPath()))
+	}
+
+	s.state.PushEvaluationStack(NewIntValue(count))
+}
+
+// endStringEvaluation consumes the output produced since the matching
+// BeginString and pushes it to the evaluation stack as a single string.
+func (s *Story) endStringEvaluation() {
+	stream := s.state.GetOutputStream()
+	var parts []string
+	var retained []RuntimeObject
+
+	consumed := 0
+	for i := len(stream) - 1; i >= 0; i-- {
+		obj := stream[i]
+		consumed++
+
+		if cmd, ok := obj.(*ControlCommand); ok && cmd.CommandType == CommandTypeBeginString {
+			break
+		}
+		switch v := obj.(type) {
+		case *Tag:
+			retained = append(retained, v)
+		case *StringValue:
+			parts = append(parts, v.Value)
+		}
+	}
+
+	s.state.PopFromOutputStream(consumed)
+
+	// Tags generated during string evaluation stay on the output stream
+	for i := len(retained) - 1; i >= 0; i-- {
+		s.state.PushToOutputStream(retained[i])
+	}
+
+	var sb strings.Builder
+	for i := len(parts) - 1; i >= 0; i-- {
+		sb.WriteString(parts[i])
+	}
+
+	s.state.SetInExpressionEvaluation(true)
+	s.state.PushEvaluationStack(NewStringValue(sb.String()))
+}
+
+// endChoiceTag consumes the output produced since the matching BeginTag and
+// pushes it to the evaluation stack as a Tag.
+func (s *Story) endChoiceTag() {
+	stream := s.state.GetOutputStream()
+	var parts []string
+
+	consumed := 0
+	for i := len(stream) - 1; i >= 0; i-- {
+		obj := stream[i]
+		consumed++
+
+		if cmd, ok := obj.(*ControlCommand); ok {
+			if cmd.CommandType != CommandTypeBeginTag {
+				s.state.AddError("Unexpected ControlCommand while extracting tag from choice")
+			}
+			break
+		}
+		if strVal, ok := obj.(*StringValue); ok {
+			parts = append(parts, strVal.Value)
 		}
+	}
 
-		s.state.InThreadGeneration = false
-		s.state.CallStack.PushThread()
+	s.state.PopFromOutputStream(consumed)
 
-		prevThread := s.state.CallStack.Threads[len(s.state.CallStack.Threads)-2]
-		elem := prevThread.CallStack[len(prevThread.CallStack)-1]
-		elem.CurrentPointer.Index++
+	var sb strings.Builder
+	for i := len(parts) - 1; i >= 0; i-- {
+		sb.WriteString(parts[i])
 	}
 
+	s.state.PushEvaluationStack(NewTag(cleanOutputWhitespace(sb.String())))
+}
+
+//nolint:gocognit
+func (s *Story) performDivert(divert *Divert) bool {
 	if divert.IsConditional {
 		if len(s.state.EvaluationStack) > 0 {
 			cond := s.state.PopEvaluationStack()
@@ -753,9 +1494,8 @@ func (s *Story) performDivert(divert *Divert) bool {
 	}
 
 	if divert.IsExternal {
-		err := s.callExternalFunction(divert.TargetPath.String(), divert.ExternalArgs)
-		if err != nil {
-			fmt.Printf("Error calling external function %s: %v\n", divert.TargetPath.String(), err)
+		if err := s.callExternalFunction(divert.TargetPath.String(), divert.ExternalArgs); err != nil {
+			s.state.AddError(err.Error())
 		}
 		return true
 	}
@@ -772,7 +1512,7 @@ func (s *Story) performDivert(divert *Divert) bool {
 	}
 
 	if divert.PushesToStack {
-		s.state.CallStack.Push(divert.StackPushType, 0, 0)
+		s.state.GetCallStack().Push(divert.StackPushType, 0, len(s.state.GetOutputStream()))
 	}
 
 	if targetPath == nil && divert.VariableDivertName == "" {
@@ -791,6 +1531,18 @@ func (s *Story) performDivert(divert *Divert) bool {
 }
 
 func (s *Story) performVariableReference(varRef *VariableReference) bool {
+	// Read count of a knot, stitch or gather rather than a variable
+	if varRef.PathForCount != nil {
+		container, ok := s.resolvePath(varRef, varRef.PathForCount).(*Container)
+		if !ok {
+			s.state.AddError("Read count target not found: " + varRef.PathForCount.String())
+			s.state.PushEvaluationStack(NewIntValue(0))
+			return true
+		}
+		s.state.PushEvaluationStack(NewIntValue(s.state.VisitCountForContainer(container)))
+		return true
+	}
+
 	val := s.state.GetVariablesState().GetVariableWithName(varRef.Name)
 	if val == nil {
 		s.state.AddWarning("Variable not found: " + varRef.Name)
@@ -813,6 +1565,9 @@ func (s *Story) performVariableAssignment(varAss *VariableAssignment) bool {
 	return true
 }
 
+// performNativeFunction calls a native function on values popped from the
+// evaluation stack. On an error, Void is pushed in place of the result, so
+// that whatever uses the result still finds a value on the evaluation stack.
 func (s *Story) performNativeFunction(nativeFunc *NativeFunctionCall) bool {
 	params := make([]RuntimeObject, nativeFunc.NumberOfParameters)
 	for i := nativeFunc.NumberOfParameters - 1; i >= 0; i-- {
@@ -820,6 +1575,8 @@ func (s *Story) performNativeFunction(nativeFunc *NativeFunctionCall) bool {
 	}
 	result, err := nativeFunc.Call(params)
 	if err != nil {
+		s.state.AddError(err.Error())
+		s.state.PushEvaluationStack(NewVoid())
 		return true
 	}
 	s.state.PushEvaluationStack(result)
@@ -2,7 +2,10 @@ package ink
 
 import (
 	"fmt"
+	"maps"
 	"math/rand"
+	"slices"
+	"strings"
 )
 
 // StoryState represents the state of the story.
@@ -17,6 +20,12 @@ type StoryState struct {
 	VisitCounts map[*Container]int // Pointer map, effectively identity map
 	TurnIndices map[*Container]int
 
+	// visitCountOrder and turnIndexOrder list the containers in VisitCounts
+	// and TurnIndices in the order they were first added, which is the order
+	// saves list them in.
+	visitCountOrder []*Container
+	turnIndexOrder  []*Container
+
 	CurrentTurnIndex int
 	StorySeed        int
 	PreviousRandom   int
@@ -24,20 +33,21 @@ type StoryState struct {
 
 	Story *Story
 
-	CurrentChoices   []*Choice
-	GeneratedChoices []*Choice
-
-	CurrentFlow    *Flow
-	NamedFlows     map[string]*Flow
-	AliveFlowNames []string
+	CurrentFlow *Flow
+	NamedFlows  map[string]*Flow
+	// flowNames lists the flows in NamedFlows in the order they were created.
+	flowNames []string
 
 	OutputStreamDirty     bool
 	OutputStreamTagsDirty bool
+	currentText           string
 	CurrentTags           []string
 	CurrentErrors         []string
 	CurrentWarnings       []string
 
-	InThreadGeneration bool
+	// patch holds the changes made while the story looks ahead past the end
+	// of a line, so that they can be discarded or applied afterwards.
+	patch *StatePatch
 }
 
 // NewStoryState creates a new StoryState.
@@ -48,10 +58,7 @@ func NewStoryState(story *Story) *StoryState {
 		TurnIndices:      make(map[*Container]int),
 		CurrentTurnIndex: -1,
 		EvaluationStack:  make([]RuntimeObject, 0),
-		CurrentChoices:   make([]*Choice, 0),
-		GeneratedChoices: make([]*Choice, 0),
 		NamedFlows:       make(map[string]*Flow),
-		AliveFlowNames:   []string{}, // Will be populated
 	}
 
 	// Seed random
@@ -73,20 +80,78 @@ func (ss *StoryState) GoToStart() {
 	}
 
 	// Initial Flow
-	ss.CurrentFlow = NewFlow("DEFAULT_FLOW", ss.Story)
-	ss.NamedFlows["DEFAULT_FLOW"] = ss.CurrentFlow
+	ss.CurrentFlow = NewFlow(DefaultFlowName, ss.Story)
+	ss.NamedFlows = map[string]*Flow{DefaultFlowName: ss.CurrentFlow}
+	ss.flowNames = []string{DefaultFlowName}
 
-	ss.OutputStreamDirty = true
-	ss.AliveFlowNames = []string{"DEFAULT_FLOW"}
+	ss.markOutputStreamDirty()
 
 	ss.VisitCounts = make(map[*Container]int)
 	ss.TurnIndices = make(map[*Container]int)
+	ss.visitCountOrder = nil
+	ss.turnIndexOrder = nil
 	ss.CurrentTurnIndex = -1
 
 	// Start
 	ss.CallStack.Reset()
 	ss.CurrentFlow.CallStack = ss.CallStack
-	ss.OutputStreamDirty = true
+	ss.markOutputStreamDirty()
+}
+
+// CopyAndStartPatching returns a copy of the state that records changes to
+// variables, visit counts and turn indices in a patch, leaving this state
+// untouched until the patch is applied. The copy shares the VariablesState.
+func (ss *StoryState) CopyAndStartPatching() *StoryState {
+	cp := &StoryState{
+		Story:            ss.Story,
+		VariablesState:   ss.VariablesState,
+		EvaluationStack:  slices.Clone(ss.EvaluationStack),
+		DivertedPointer:  ss.DivertedPointer,
+		VisitCounts:      ss.VisitCounts,
+		TurnIndices:      ss.TurnIndices,
+		visitCountOrder:  slices.Clip(ss.visitCountOrder),
+		turnIndexOrder:   slices.Clip(ss.turnIndexOrder),
+		CurrentTurnIndex: ss.CurrentTurnIndex,
+		StorySeed:        ss.StorySeed,
+		PreviousRandom:   ss.PreviousRandom,
+		DidSafeExit:      ss.DidSafeExit,
+		CurrentErrors:    slices.Clone(ss.CurrentErrors),
+		CurrentWarnings:  slices.Clone(ss.CurrentWarnings),
+		patch:            NewStatePatch(ss.patch),
+	}
+
+	cp.CurrentFlow = ss.CurrentFlow.Copy()
+	cp.CallStack = cp.CurrentFlow.CallStack
+	cp.NamedFlows = maps.Clone(ss.NamedFlows)
+	cp.flowNames = slices.Clone(ss.flowNames)
+	cp.NamedFlows[cp.CurrentFlow.Name] = cp.CurrentFlow
+	cp.markOutputStreamDirty()
+
+	cp.VariablesState.SetCallStack(cp.GetCallStack())
+	cp.VariablesState.Patch = cp.patch
+	return cp
+}
+
+// RestoreAfterPatch points the shared VariablesState back at this state,
+// after a copy made by CopyAndStartPatching has been abandoned.
+func (ss *StoryState) RestoreAfterPatch() {
+	ss.VariablesState.SetCallStack(ss.GetCallStack())
+	ss.VariablesState.Patch = ss.patch
+}
+
+// ApplyAnyPatch commits the changes recorded since CopyAndStartPatching.
+func (ss *StoryState) ApplyAnyPatch() {
+	if ss.patch == nil {
+		return
+	}
+	ss.VariablesState.ApplyPatch()
+	for _, container := range ss.patch.visitCountOrder {
+		ss.setVisitCount(container, ss.patch.VisitCounts[container])
+	}
+	for _, container := range ss.patch.turnIndexOrder {
+		ss.setTurnIndex(container, ss.patch.TurnIndices[container])
+	}
+	ss.patch = nil
 }
 
 // GetCallStack returns the current call stack.
@@ -107,6 +172,11 @@ func (ss *StoryState) SetCurrentPointer(p Pointer) {
 	ss.GetCallStack().CurrentElement().CurrentPointer = p
 }
 
+// GetPreviousPointer returns the pointer to the previously executed content.
+func (ss *StoryState) GetPreviousPointer() Pointer {
+	return ss.GetCallStack().CurrentThread().PreviousPointer
+}
+
 // SetPreviousPointer sets the previous pointer.
 func (ss *StoryState) SetPreviousPointer(p Pointer) {
 	ss.GetCallStack().CurrentThread().PreviousPointer = p
@@ -122,35 +192,118 @@ func (ss *StoryState) SetDivertedPointer(p Pointer) {
 	ss.DivertedPointer = p
 }
 
-// PopCallStack pops the call stack.
+// PopCallStack pops the call stack. At the end of a function call, any
+// whitespace output at the end of the function is trimmed.
 func (ss *StoryState) PopCallStack(popType PushPopType) error {
-	// Security Check: Clean up stack after tunnels to prevent "Dirty Stack" bugs
-	var heightWhenPushed int
-	if popType == PushPopTypeTunnel {
-		heightWhenPushed = ss.GetCallStack().CurrentElement().EvaluationStackHeightWhenPushed
+	if ss.GetCallStack().CurrentElement().Type == PushPopTypeFunction {
+		ss.trimWhitespaceFromFunctionEnd()
 	}
+	return ss.GetCallStack().Pop(popType)
+}
+
+// trimWhitespaceFromFunctionEnd removes newlines and inline whitespace that
+// the current function pushed to the end of the output stream.
+func (ss *StoryState) trimWhitespaceFromFunctionEnd() {
+	functionStartPoint := ss.GetCallStack().CurrentElement().FunctionStartInOutputStream
 
-	err := ss.GetCallStack().Pop(popType)
-	if err != nil {
-		return err
+	// If the start point has become -1, it means that some non-whitespace
+	// text has been pushed, so it's safe to go as far back as we're able.
+	if functionStartPoint == -1 {
+		functionStartPoint = 0
 	}
 
-	// For tunnels, we forcefully clean up any debris left on the evaluation stack
-	if popType == PushPopTypeTunnel {
-		if len(ss.EvaluationStack) > heightWhenPushed {
-			ss.EvaluationStack = ss.EvaluationStack[:heightWhenPushed]
+	stream := ss.CurrentFlow.OutputStream
+	for i := len(stream) - 1; i >= functionStartPoint; i-- {
+		txt, ok := stream[i].(*StringValue)
+		if !ok {
+			continue
 		}
+		if !txt.isNewline && !txt.isInlineWhitespace {
+			break
+		}
+		stream = append(stream[:i], stream[i+1:]...)
+		ss.markOutputStreamDirty()
 	}
+	ss.CurrentFlow.OutputStream = stream
+}
 
+// TryExitFunctionEvaluationFromGame tries to exit function evaluation from
+// game, returning whether it did.
+func (ss *StoryState) TryExitFunctionEvaluationFromGame() bool {
+	if ss.GetCallStack().CurrentElement().Type != PushPopTypeFunctionEvaluationFromGame {
+		return false
+	}
+	ss.SetCurrentPointer(NullPointer)
+	ss.DidSafeExit = true
+	return true
+}
+
+// StartFunctionEvaluationFromGame pushes a frame to run the given function
+// container, with its arguments on the evaluation stack.
+func (ss *StoryState) StartFunctionEvaluationFromGame(funcContainer *Container, args []any) error {
+	evaluationStackHeight := len(ss.EvaluationStack)
+	ss.GetCallStack().Push(PushPopTypeFunctionEvaluationFromGame, evaluationStackHeight, 0)
+	ss.SetCurrentPointer(StartOf(funcContainer))
+
+	for _, arg := range args {
+		obj, err := NativeToRuntimeObject(arg)
+		if err == nil && obj == nil {
+			err = fmt.Errorf("argument was nil")
+		}
+		if err != nil {
+			// Leave the state as it was
+			ss.EvaluationStack = ss.EvaluationStack[:evaluationStackHeight]
+			_ = ss.GetCallStack().Pop(PushPopTypeFunctionEvaluationFromGame)
+			return fmt.Errorf("ink arguments when calling EvaluateFunction must be int, float, string, bool or list: %w", err)
+		}
+		ss.PushEvaluationStack(obj)
+	}
 	return nil
 }
 
-// TryExitFunctionEvaluationFromGame tries to exit function evaluation from game.
-func (ss *StoryState) TryExitFunctionEvaluationFromGame() {
-	if ss.GetCallStack().CanPopType(PushPopTypeFunctionEvaluationFromGame) {
-		err := ss.GetCallStack().Pop(PushPopTypeFunctionEvaluationFromGame)
-		if err != nil {
-			ss.AddError(fmt.Sprintf("Failed to pop callstack: %v", err))
+// CompleteFunctionEvaluationFromGame pops the frame pushed by
+// StartFunctionEvaluationFromGame and returns the function's return value,
+// or nil if it didn't return one.
+func (ss *StoryState) CompleteFunctionEvaluationFromGame() (RuntimeObject, error) {
+	if ss.GetCallStack().CurrentElement().Type != PushPopTypeFunctionEvaluationFromGame {
+		return nil, fmt.Errorf("expected external function evaluation to be complete")
+	}
+
+	originalEvaluationStackHeight := ss.GetCallStack().CurrentElement().EvaluationStackHeightWhenPushed
+
+	// Pop everything the function left behind, in case the caller passed
+	// too many arguments. The topmost value is the return value.
+	var returnedObj RuntimeObject
+	for len(ss.EvaluationStack) > originalEvaluationStackHeight {
+		poppedObj := ss.PopEvaluationStack()
+		if returnedObj == nil {
+			returnedObj = poppedObj
+		}
+	}
+
+	if err := ss.PopCallStack(PushPopTypeFunctionEvaluationFromGame); err != nil {
+		return nil, err
+	}
+
+	if _, ok := returnedObj.(*Void); ok {
+		return nil, nil
+	}
+	return returnedObj, nil
+}
+
+// abortFunctionEvaluationFromGame unwinds the call stack and evaluation stack
+// to how they were before StartFunctionEvaluationFromGame, for when the
+// function didn't run to completion.
+func (ss *StoryState) abortFunctionEvaluationFromGame() {
+	cs := ss.GetCallStack()
+	for cs.CanPop() {
+		el := cs.CurrentElement()
+		_ = cs.Pop(el.Type)
+		if el.Type == PushPopTypeFunctionEvaluationFromGame {
+			if len(ss.EvaluationStack) > el.EvaluationStackHeightWhenPushed {
+				ss.EvaluationStack = ss.EvaluationStack[:el.EvaluationStackHeightWhenPushed]
+			}
+			return
 		}
 	}
 }
@@ -167,6 +320,19 @@ func (ss *StoryState) SetInExpressionEvaluation(active bool) {
 
 // PushEvaluationStack pushes an object to the evaluation stack.
 func (ss *StoryState) PushEvaluationStack(obj RuntimeObject) {
+	// Update the list's origins from its items, so operations such as LIST_ALL
+	// and list + int know which definitions the list belongs to
+	if listVal, ok := obj.(*ListValue); ok && listVal.Value != nil && listVal.Value.OriginNames() != nil {
+		if defs := ss.Story.GetListDefinitions(); defs != nil {
+			rawList := listVal.Value
+			rawList.Origins = make([]*ListDefinition, 0, len(rawList.Origins))
+			for _, name := range rawList.OriginNames() {
+				if def, ok := defs.Lists[name]; ok {
+					rawList.Origins = append(rawList.Origins, def)
+				}
+			}
+		}
+	}
 	ss.EvaluationStack = append(ss.EvaluationStack, obj)
 }
 
@@ -188,23 +354,295 @@ func (ss *StoryState) PeekEvaluationStack() RuntimeObject {
 	return ss.EvaluationStack[len(ss.EvaluationStack)-1]
 }
 
-// OutputStreamEndsInNewline checks if the output stream ends in a newline.
+// OutputStreamEndsInNewline checks if the output stream ends in a newline,
+// ignoring any inline whitespace after it.
 func (ss *StoryState) OutputStreamEndsInNewline() bool {
-	if len(ss.CurrentFlow.OutputStream) == 0 {
-		return false
+	stream := ss.CurrentFlow.OutputStream
+	for i := len(stream) - 1; i >= 0; i-- {
+		if _, ok := stream[i].(*ControlCommand); ok {
+			break
+		}
+		if text, ok := stream[i].(*StringValue); ok {
+			if text.isNewline {
+				return true
+			}
+			if !text.isInlineWhitespace {
+				break
+			}
+		}
 	}
-	lastObj := ss.CurrentFlow.OutputStream[len(ss.CurrentFlow.OutputStream)-1]
-	if strVal, ok := lastObj.(*StringValue); ok {
-		return strVal.isNewline || strVal.Value == "\n"
+	return false
+}
+
+// outputStreamContainsContent reports whether any text has been output.
+func (ss *StoryState) outputStreamContainsContent() bool {
+	for _, obj := range ss.CurrentFlow.OutputStream {
+		if _, ok := obj.(*StringValue); ok {
+			return true
+		}
 	}
 	return false
 }
 
-// PushToOutputStream pushes an object to the output stream.
+// PushToOutputStream pushes an object to the output stream. Text with
+// leading or trailing newlines is split up first, so that each newline can
+// be handled on its own.
 func (ss *StoryState) PushToOutputStream(obj RuntimeObject) {
-	// Javas implementation simply adds to list
-	ss.CurrentFlow.OutputStream = append(ss.CurrentFlow.OutputStream, obj)
-	ss.OutputStreamDirty = true
+	if text, ok := obj.(*StringValue); ok {
+		if texts := trySplittingHeadTailWhitespace(text); texts != nil {
+			for _, textObj := range texts {
+				ss.pushToOutputStreamIndividual(textObj)
+			}
+			ss.markOutputStreamDirty()
+			return
+		}
+	}
+
+	ss.pushToOutputStreamIndividual(obj)
+	ss.markOutputStreamDirty()
+}
+
+// pushToOutputStreamIndividual applies glue and whitespace rules as text is
+// output:
+//   - Glue removes the newlines before it, and any newline after it until
+//     some real text is output.
+//   - Newlines at the start of a function's output are dropped, so that
+//     calling a function inline doesn't break the line it is called from.
+//   - A newline is never output at the very start, or straight after
+//     another newline.
+func (ss *StoryState) pushToOutputStreamIndividual(obj RuntimeObject) {
+	includeInOutput := true
+
+	switch v := obj.(type) {
+	case *Glue:
+		// Found glue, so chomp any whitespace before it
+		ss.trimNewlinesFromOutputStream()
+	case *StringValue:
+		functionTrimIndex := -1
+		currEl := ss.GetCallStack().CurrentElement()
+		if currEl.Type == PushPopTypeFunction {
+			functionTrimIndex = currEl.FunctionStartInOutputStream
+		}
+
+		// Do we need to trim the start of the text because of glue, or a
+		// function call? Don't trim past the start of a string evaluation
+		// section.
+		glueTrimIndex := -1
+		stream := ss.CurrentFlow.OutputStream
+		for i := len(stream) - 1; i >= 0; i-- {
+			if _, ok := stream[i].(*Glue); ok {
+				glueTrimIndex = i
+				break
+			}
+			if cmd, ok := stream[i].(*ControlCommand); ok && cmd.CommandType == CommandTypeBeginString {
+				if i >= functionTrimIndex {
+					functionTrimIndex = -1
+				}
+				break
+			}
+		}
+
+		trimIndex := functionTrimIndex
+		if glueTrimIndex != -1 && (functionTrimIndex == -1 || glueTrimIndex < functionTrimIndex) {
+			trimIndex = glueTrimIndex
+		}
+
+		switch {
+		case trimIndex != -1:
+			if v.isNewline {
+				includeInOutput = false
+			} else if !v.isInlineWhitespace {
+				// Some real text, so the glue and function start trimming
+				// are done
+				if glueTrimIndex > -1 {
+					ss.removeExistingGlue()
+				}
+				if functionTrimIndex > -1 {
+					// Tell all functions in the call stack that we have seen
+					// proper text, so trimming whitespace at the start is done.
+					elements := ss.GetCallStack().Elements()
+					for i := len(elements) - 1; i >= 0 && elements[i].Type == PushPopTypeFunction; i-- {
+						elements[i].FunctionStartInOutputStream = -1
+					}
+				}
+			}
+		case v.isNewline:
+			if ss.OutputStreamEndsInNewline() || !ss.outputStreamContainsContent() {
+				includeInOutput = false
+			}
+		}
+	}
+
+	if includeInOutput {
+		ss.CurrentFlow.OutputStream = append(ss.CurrentFlow.OutputStream, obj)
+	}
+}
+
+// trimNewlinesFromOutputStream removes the trailing whitespace from the
+// output stream, from the last newline onwards.
+func (ss *StoryState) trimNewlinesFromOutputStream() {
+	stream := ss.CurrentFlow.OutputStream
+
+	removeWhitespaceFrom := -1
+	for i := len(stream) - 1; i >= 0; i-- {
+		if _, ok := stream[i].(*ControlCommand); ok {
+			break
+		}
+		if text, ok := stream[i].(*StringValue); ok {
+			if !text.isNewline && !text.isInlineWhitespace {
+				break
+			}
+			if text.isNewline {
+				removeWhitespaceFrom = i
+			}
+		}
+	}
+
+	// Remove the whitespace, but keep any other objects such as glue
+	if removeWhitespaceFrom >= 0 {
+		kept := stream[:removeWhitespaceFrom]
+		for _, obj := range stream[removeWhitespaceFrom:] {
+			if _, ok := obj.(*StringValue); !ok {
+				kept = append(kept, obj)
+			}
+		}
+		ss.CurrentFlow.OutputStream = kept
+	}
+	ss.markOutputStreamDirty()
+}
+
+// removeExistingGlue removes the glue since the last control command.
+func (ss *StoryState) removeExistingGlue() {
+	stream := ss.CurrentFlow.OutputStream
+	for i := len(stream) - 1; i >= 0; i-- {
+		if _, ok := stream[i].(*Glue); ok {
+			stream = append(stream[:i], stream[i+1:]...)
+		} else if _, ok := stream[i].(*ControlCommand); ok {
+			break
+		}
+	}
+	ss.CurrentFlow.OutputStream = stream
+	ss.markOutputStreamDirty()
+}
+
+// trySplittingHeadTailWhitespace splits text with leading or trailing
+// newlines, e.g. "\n  hello  \n", into separate newline, inline whitespace
+// and text values. It returns nil if there's nothing to split.
+func trySplittingHeadTailWhitespace(single *StringValue) []*StringValue {
+	str := single.Value
+
+	headFirstNewlineIdx := -1
+	headLastNewlineIdx := -1
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		if c == '\n' {
+			if headFirstNewlineIdx == -1 {
+				headFirstNewlineIdx = i
+			}
+			headLastNewlineIdx = i
+		} else if c != ' ' && c != '\t' {
+			break
+		}
+	}
+
+	tailLastNewlineIdx := -1
+	tailFirstNewlineIdx := -1
+	for i := len(str) - 1; i >= 0; i-- {
+		c := str[i]
+		if c == '\n' {
+			if tailLastNewlineIdx == -1 {
+				tailLastNewlineIdx = i
+			}
+			tailFirstNewlineIdx = i
+		} else if c != ' ' && c != '\t' {
+			break
+		}
+	}
+
+	// No splitting to be done?
+	if headFirstNewlineIdx == -1 && tailLastNewlineIdx == -1 {
+		return nil
+	}
+
+	var texts []*StringValue
+	innerStrStart := 0
+	innerStrEnd := len(str)
+
+	if headFirstNewlineIdx != -1 {
+		if headFirstNewlineIdx > 0 {
+			texts = append(texts, NewStringValue(str[:headFirstNewlineIdx]))
+		}
+		texts = append(texts, NewStringValue("\n"))
+		innerStrStart = headLastNewlineIdx + 1
+	}
+
+	if tailLastNewlineIdx != -1 {
+		innerStrEnd = tailFirstNewlineIdx
+	}
+
+	if innerStrEnd > innerStrStart {
+		texts = append(texts, NewStringValue(str[innerStrStart:innerStrEnd]))
+	}
+
+	if tailLastNewlineIdx != -1 && tailFirstNewlineIdx > headLastNewlineIdx {
+		texts = append(texts, NewStringValue("\n"))
+		if tailLastNewlineIdx < len(str)-1 {
+			texts = append(texts, NewStringValue(str[tailLastNewlineIdx+1:]))
+		}
+	}
+
+	return texts
+}
+
+// PopFromOutputStream removes the given number of objects from the end of
+// the output stream.
+func (ss *StoryState) PopFromOutputStream(count int) {
+	stream := ss.CurrentFlow.OutputStream
+	ss.CurrentFlow.OutputStream = stream[:len(stream)-count]
+	ss.markOutputStreamDirty()
+}
+
+// InStringEvaluation returns true if the output stream is currently being
+// captured to build a string, i.e. there is an unmatched BeginString.
+func (ss *StoryState) InStringEvaluation() bool {
+	stream := ss.CurrentFlow.OutputStream
+	for i := len(stream) - 1; i >= 0; i-- {
+		if cmd, ok := stream[i].(*ControlCommand); ok && cmd.CommandType == CommandTypeBeginString {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentText returns the text in the output stream, leaving out the text
+// of tags, with its whitespace cleaned up as by cleanOutputWhitespace. The
+// text is cached until the output stream changes.
+func (ss *StoryState) CurrentText() string {
+	if !ss.OutputStreamDirty {
+		return ss.currentText
+	}
+
+	var sb strings.Builder
+	inTag := false
+	for _, obj := range ss.GetOutputStream() {
+		switch v := obj.(type) {
+		case *StringValue:
+			if !inTag {
+				sb.WriteString(v.Value)
+			}
+		case *ControlCommand:
+			switch v.CommandType {
+			case CommandTypeBeginTag:
+				inTag = true
+			case CommandTypeEndTag:
+				inTag = false
+			}
+		}
+	}
+
+	ss.currentText = cleanOutputWhitespace(sb.String())
+	ss.OutputStreamDirty = false
+	return ss.currentText
 }
 
 // GetOutputStream returns the current flow's output stream.
@@ -212,7 +650,8 @@ func (ss *StoryState) GetOutputStream() []RuntimeObject {
 	return ss.CurrentFlow.OutputStream
 }
 
-// GetGeneratedChoices returns the list of choices generated in the current chunk.
+// GetGeneratedChoices returns the choices generated in the current flow,
+// including invisible default choices.
 func (ss *StoryState) GetGeneratedChoices() []*Choice {
 	return ss.CurrentFlow.CurrentChoices
 }
@@ -255,8 +694,166 @@ func (ss *StoryState) GetCurrentWarnings() []string {
 // ResetOutput resets the output stream.
 func (ss *StoryState) ResetOutput() {
 	ss.CurrentFlow.OutputStream = make([]RuntimeObject, 0)
-	ss.CurrentFlow.CurrentChoices = make([]*Choice, 0)
+	ss.markOutputStreamDirty()
+}
+
+// markOutputStreamDirty flags the text and tags derived from the output
+// stream as needing to be rebuilt.
+func (ss *StoryState) markOutputStreamDirty() {
 	ss.OutputStreamDirty = true
+	ss.OutputStreamTagsDirty = true
+}
+
+// GetCurrentTags returns the tags in the current output stream, in order.
+// Text between a BeginTag and EndTag command forms a single tag, which allows
+// tags to contain dynamic content. Legacy Tag objects are included as-is.
+func (ss *StoryState) GetCurrentTags() []string {
+	if !ss.OutputStreamTagsDirty && ss.CurrentTags != nil {
+		return ss.CurrentTags
+	}
+
+	tags := make([]string, 0)
+	inTag := false
+	var sb strings.Builder
+
+	flushTag := func() {
+		if sb.Len() > 0 {
+			tags = append(tags, cleanOutputWhitespace(sb.String()))
+			sb.Reset()
+		}
+	}
+
+	for _, obj := range ss.GetOutputStream() {
+		switch v := obj.(type) {
+		case *ControlCommand:
+			switch v.CommandType {
+			case CommandTypeBeginTag:
+				if inTag {
+					flushTag()
+				}
+				inTag = true
+			case CommandTypeEndTag:
+				flushTag()
+				inTag = false
+			}
+		case *StringValue:
+			if inTag {
+				sb.WriteString(v.Value)
+			}
+		case *Tag:
+			if !inTag && v.Text != "" {
+				tags = append(tags, v.Text)
+			}
+		}
+	}
+	flushTag()
+
+	ss.CurrentTags = tags
+	ss.OutputStreamTagsDirty = false
+	return tags
+}
+
+// cleanOutputWhitespace collapses runs of inline whitespace into a single
+// space and removes inline whitespace at the start and end of each line.
+func cleanOutputWhitespace(str string) string {
+	var sb strings.Builder
+	sb.Grow(len(str))
+
+	currentWhitespaceStart := -1
+	startOfLine := 0
+
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		isInlineWhitespace := c == ' ' || c == '\t'
+
+		if isInlineWhitespace && currentWhitespaceStart == -1 {
+			currentWhitespaceStart = i
+		}
+
+		if !isInlineWhitespace {
+			if c != '\n' && currentWhitespaceStart > 0 && currentWhitespaceStart != startOfLine {
+				sb.WriteByte(' ')
+			}
+			currentWhitespaceStart = -1
+		}
+
+		if c == '\n' {
+			startOfLine = i + 1
+		}
+
+		if !isInlineWhitespace {
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
+
+// ForceEnd ends every thread of the current flow, as happens at an END.
+func (ss *StoryState) ForceEnd() {
+	ss.GetCallStack().Reset()
+
+	ss.CurrentFlow.CurrentChoices = make([]*Choice, 0)
+
+	ss.SetCurrentPointer(NullPointer)
+	ss.SetPreviousPointer(NullPointer)
+
+	ss.DidSafeExit = true
+	ss.CurrentFlow.DidEnd = true
+}
+
+// SwitchFlow makes the named flow current, creating it if it doesn't exist
+// yet. A new flow starts at the top of the story.
+func (ss *StoryState) SwitchFlow(flowName string) {
+	if flowName == ss.CurrentFlow.Name {
+		return
+	}
+
+	flow, ok := ss.NamedFlows[flowName]
+	if !ok {
+		flow = NewFlow(flowName, ss.Story)
+		ss.NamedFlows[flowName] = flow
+		ss.flowNames = append(ss.flowNames, flowName)
+	}
+
+	ss.CurrentFlow = flow
+	ss.VariablesState.SetCallStack(flow.CallStack)
+
+	// Cause text to be regenerated from output stream if necessary
+	ss.markOutputStreamDirty()
+}
+
+// SwitchToDefaultFlow makes the default flow current.
+func (ss *StoryState) SwitchToDefaultFlow() {
+	ss.SwitchFlow(DefaultFlowName)
+}
+
+// RemoveFlow destroys the named flow. If it is the current flow, the story
+// switches back to the default flow first.
+func (ss *StoryState) RemoveFlow(flowName string) error {
+	if flowName == DefaultFlowName {
+		return fmt.Errorf("cannot destroy default flow")
+	}
+
+	if ss.CurrentFlow.Name == flowName {
+		ss.SwitchToDefaultFlow()
+	}
+	delete(ss.NamedFlows, flowName)
+	ss.flowNames = slices.DeleteFunc(ss.flowNames, func(name string) bool { return name == flowName })
+	return nil
+}
+
+// AliveFlowNames returns the names of the flows that exist besides the
+// default flow, in alphabetical order.
+func (ss *StoryState) AliveFlowNames() []string {
+	names := make([]string, 0, len(ss.NamedFlows))
+	for name := range ss.NamedFlows {
+		if name != DefaultFlowName {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
 }
 
 // SetDidSafeExit sets the safe exit flag.
@@ -269,25 +866,88 @@ func (ss *StoryState) GetVariablesState() *VariablesState {
 	return ss.VariablesState
 }
 
-// VisitCountForContainer returns the visit count for a container.
+// VisitCountForContainer returns the visit count for a container. Reading the
+// count of a container that the compiler didn't mark for counting is an error.
 func (ss *StoryState) VisitCountForContainer(container *Container) int {
-	if count, ok := ss.VisitCounts[container]; ok {
-		return count
+	if !container.VisitsShouldBeCounted {
+		ss.AddError(fmt.Sprintf("Read count for target (%s) unknown. The story may need to be compiled with countAllVisits flag (-c).", container.GetPath()))
+		return 0
+	}
+	return ss.visitCount(container)
+}
+
+// visitCount returns the visit count for a container, including any change
+// recorded in the patch.
+func (ss *StoryState) visitCount(container *Container) int {
+	if ss.patch != nil {
+		if count, ok := ss.patch.VisitCounts[container]; ok {
+			return count
+		}
 	}
-	return 0
+	return ss.VisitCounts[container]
 }
 
 // IncrementVisitCountForContainer increments the visit count for a container.
 func (ss *StoryState) IncrementVisitCountForContainer(container *Container) {
-	count := 0
-	if c, ok := ss.VisitCounts[container]; ok {
-		count = c
+	count := ss.visitCount(container) + 1
+	if ss.patch != nil {
+		ss.patch.SetVisitCount(container, count)
+		return
+	}
+	ss.setVisitCount(container, count)
+}
+
+// setVisitCount sets the visit count for a container, remembering the order
+// containers are first counted in.
+func (ss *StoryState) setVisitCount(container *Container, count int) {
+	if _, ok := ss.VisitCounts[container]; !ok {
+		ss.visitCountOrder = append(ss.visitCountOrder, container)
 	}
-	count++
 	ss.VisitCounts[container] = count
 }
 
+// VisitCountAtPathString returns the number of times the container at the
+// given path has been visited.
+func (ss *StoryState) VisitCountAtPathString(pathString string) int {
+	content := ss.Story.MainContent.ContentAtPath(NewPathFromString(pathString))
+	container, ok := content.(*Container)
+	if !ok {
+		return 0
+	}
+	return ss.visitCount(container)
+}
+
+// TurnsSinceForContainer returns the number of turns since the container was
+// last visited, or -1 if it has never been visited.
+func (ss *StoryState) TurnsSinceForContainer(container *Container) int {
+	if !container.TurnIndexShouldBeCounted {
+		ss.AddError(fmt.Sprintf("TURNS_SINCE() for target (%s) unknown. The story may need to be compiled with countAllVisits flag (-c).", container.GetPath()))
+	}
+	if ss.patch != nil {
+		if index, ok := ss.patch.TurnIndices[container]; ok {
+			return ss.CurrentTurnIndex - index
+		}
+	}
+	if index, ok := ss.TurnIndices[container]; ok {
+		return ss.CurrentTurnIndex - index
+	}
+	return -1
+}
+
 // RecordTurnIndexVisitToContainer records the turn index visit to a container.
 func (ss *StoryState) RecordTurnIndexVisitToContainer(container *Container) {
-	ss.TurnIndices[container] = ss.CurrentTurnIndex
+	if ss.patch != nil {
+		ss.patch.SetTurnIndex(container, ss.CurrentTurnIndex)
+		return
+	}
+	ss.setTurnIndex(container, ss.CurrentTurnIndex)
+}
+
+// setTurnIndex sets the turn index for a container, remembering the order
+// containers are first counted in.
+func (ss *StoryState) setTurnIndex(container *Container, index int) {
+	if _, ok := ss.TurnIndices[container]; !ok {
+		ss.turnIndexOrder = append(ss.turnIndexOrder, container)
+	}
+	ss.TurnIndices[container] = index
 }
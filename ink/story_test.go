@@ -23,3 +23,165 @@ func TestHelloWorld(t *testing.T) {
 		t.Errorf("got %q, want %q", result, expected)
 	}
 }
+
+func TestEndStopsEveryThread(t *testing.T) {
+	// <- ending
+	// Never reached.
+	// == ending ==
+	// The end. -> END
+	json := `{"root": [["thread", {"->": "ending"}, "^Never reached.", "\n", "done"], "done", {"ending": ["^The end.", "\n", "end", null]}], "inkVersion": 21}`
+
+	story, err := NewStory(json)
+	if err != nil {
+		t.Fatalf("NewStory failed: %v", err)
+	}
+	result, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if result != "The end.\n" {
+		t.Errorf("got %q, want %q", result, "The end.\n")
+	}
+	if !story.HasEnded() || story.CanContinue() {
+		t.Errorf("Expected the story to have ended")
+	}
+	if story.State().HasWarning() {
+		t.Errorf("Unexpected warnings: %v", story.State().GetCurrentWarnings())
+	}
+}
+
+func TestDoneDoesNotEndStory(t *testing.T) {
+	json := `{"root": [["^Hello", "\n", "done"], "done", {"elsewhere": ["^Elsewhere", "\n", "end", null]}], "inkVersion": 21}`
+
+	story, err := NewStory(json)
+	if err != nil {
+		t.Fatalf("NewStory failed: %v", err)
+	}
+	if _, err := story.ContinueMaximally(); err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if story.HasEnded() || story.CanContinue() {
+		t.Errorf("Expected the story to be paused at DONE")
+	}
+	if story.State().HasWarning() {
+		t.Errorf("Unexpected warnings: %v", story.State().GetCurrentWarnings())
+	}
+
+	if err := story.ChoosePathString("elsewhere"); err != nil {
+		t.Fatalf("ChoosePathString failed: %v", err)
+	}
+	if _, err := story.ContinueMaximally(); err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if !story.HasEnded() {
+		t.Errorf("Expected the story to have ended")
+	}
+}
+
+func TestRanOutOfContentWarning(t *testing.T) {
+	json := `{"root": [["^Hello", "\n"], null], "inkVersion": 21}`
+
+	story, err := NewStory(json)
+	if err != nil {
+		t.Fatalf("NewStory failed: %v", err)
+	}
+	if _, err := story.ContinueMaximally(); err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	want := "ran out of content. Do you need a '-> DONE' or '-> END'?"
+	if warnings := story.State().GetCurrentWarnings(); len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("Expected warning %q, got %v", want, warnings)
+	}
+	if story.HasEnded() {
+		t.Errorf("Running out of content is not an END")
+	}
+
+	// A safe exit left over from an earlier Continue, such as one that
+	// failed part way, doesn't hide the warning
+	story, err = NewStory(json)
+	if err != nil {
+		t.Fatalf("NewStory failed: %v", err)
+	}
+	story.State().SetDidSafeExit(true)
+	if _, err := story.ContinueMaximally(); err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if warnings := story.State().GetCurrentWarnings(); len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("Expected warning %q after a stale safe exit, got %v", want, warnings)
+	}
+}
+
+func TestMissingTunnelReturnWarning(t *testing.T) {
+	// -> tun ->, where tun has no ->-> at its end
+	json := `{"root": [[{"->t->": "tun"}, "^After tunnel.", "\n", "done"], "done", {"tun": ["^In tunnel.", "\n", null]}], "inkVersion": 21}`
+
+	story, err := NewStory(json)
+	if err != nil {
+		t.Fatalf("NewStory failed: %v", err)
+	}
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "In tunnel.\n" {
+		t.Errorf("Expected the tunnel not to return, got %q", text)
+	}
+	want := "unexpectedly reached end of content. Do you need a '->->' to return from a tunnel?"
+	if warnings := story.State().GetCurrentWarnings(); len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("Expected warning %q, got %v", want, warnings)
+	}
+}
+
+func TestLookaheadIsRewound(t *testing.T) {
+	// VAR x = 0
+	// A
+	// ~ x = 5
+	// B
+	jsonStr := `{"inkVersion":21,"root":[["^A","\n","ev",5,"/ev",{"VAR=":"x","re":true},"^B","\n","done"],"done",
+		{"global decl":["ev",0,{"VAR=":"x"},"/ev","end",null]}],"listDefs":{}}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("NewStory failed: %v", err)
+	}
+
+	// Continue looks ahead past the newline to check for glue, but the
+	// assignment after it must not be kept until its line is reached.
+	expected := []struct {
+		Text string
+		X    int
+	}{
+		{Text: "A\n", X: 0},
+		{Text: "B\n", X: 5},
+	}
+	for _, want := range expected {
+		text, err := story.Continue()
+		if err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		x, _ := story.Variables().Get("x")
+		if text != want.Text || x != want.X {
+			t.Errorf("Got %q with x = %v, want %q with x = %d", text, x, want.Text, want.X)
+		}
+	}
+}
+
+func TestConditionalTextWhitespace(t *testing.T) {
+	// VAR b = false
+	//   Hello {b: big} world
+	jsonStr := `{"inkVersion":21,"root":[["^  Hello ","ev",{"VAR?":"b"},"/ev",[{"->":".^.b","c":true},{"b":["^ big",{"->":"0.5"},null]}],"nop","^ world\t","\n","done"],"done",
+		{"global decl":["ev",false,{"VAR=":"b"},"/ev","end",null]}],"listDefs":{}}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("NewStory failed: %v", err)
+	}
+	text, err := story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	// Runs of spaces collapse, and spaces at the start and end of the line go
+	if text != "Hello world\n" {
+		t.Errorf("Expected %q, got %q", "Hello world\n", text)
+	}
+}
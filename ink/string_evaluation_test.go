@@ -0,0 +1,75 @@
+package ink
+
+import "testing"
+
+func TestStringEvaluation(t *testing.T) {
+	cases := []struct {
+		Name     string
+		JSON     string
+		Expected string
+	}{
+		{
+			// VAR name = "Sam"
+			// ~ temp greeting = "Hello {name}!"
+			// {greeting}
+			Name:     "Stored in a variable",
+			JSON:     `{"inkVersion":21,"root":[["ev","str","^Hello ","ev",{"VAR?":"name"},"out","/ev","^!","/str","/ev",{"temp=":"greeting"},"ev",{"VAR?":"greeting"},"out","/ev","\n","done"],"done",{"global decl":["ev","str","^Sam","/str",{"VAR=":"name"},"/ev","end",null]}],"listDefs":{}}`,
+			Expected: "Hello Sam!\n",
+		},
+		{
+			// {shout("Hi {name}")}
+			// === function shout(x) ===
+			// ~ return x + "!"
+			Name:     "Function argument",
+			JSON:     `{"inkVersion":21,"root":[["ev","str","^Hi ","ev",{"VAR?":"name"},"out","/ev","/str",{"f()":"shout"},"out","/ev","\n","done"],"done",{"shout":[{"temp=":"x"},"ev",{"VAR?":"x"},"str","^!","/str","+","/ev","~ret",null],"global decl":["ev","str","^Sam","/str",{"VAR=":"name"},"/ev","end",null]}],"listDefs":{}}`,
+			Expected: "Hi Sam!\n",
+		},
+		{
+			// ~ temp s = "A {f()} B"
+			// [{s}]
+			// === function f ===
+			// hi
+			Name:     "Function output captured",
+			JSON:     `{"inkVersion":21,"root":[["ev","str","^A ","ev",{"f()":"f"},"out","/ev","^ B","/str","/ev",{"temp=":"s"},"^[","ev",{"VAR?":"s"},"out","/ev","^]","\n","done"],"done",{"f":["\n","^hi","\n",null]}],"listDefs":{}}`,
+			Expected: "[A hi B]\n",
+		},
+		{
+			// {"x{"y{1}"}z"}
+			Name:     "Nested strings",
+			JSON:     `{"inkVersion":21,"root":[["ev","str","^x","ev","str","^y","ev",1,"out","/ev","/str","out","/ev","^z","/str","out","/ev","\n","done"],"done",null],"listDefs":{}}`,
+			Expected: "xy1z\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			story, err := NewStory(tc.JSON)
+			if err != nil {
+				t.Fatalf("NewStory failed: %v", err)
+			}
+			text, err := story.ContinueMaximally()
+			if err != nil {
+				t.Fatalf("ContinueMaximally failed: %v", err)
+			}
+			if text != tc.Expected {
+				t.Errorf("Expected %q, got %q", tc.Expected, text)
+			}
+			if story.State().HasError() {
+				t.Errorf("Unexpected errors: %v", story.State().GetCurrentErrors())
+			}
+		})
+	}
+}
+
+func TestStringEvaluationOutsideExpression(t *testing.T) {
+	story, err := NewStory(`{"inkVersion":21,"root":[["str","^x","/str","done"],"done",null],"listDefs":{}}`)
+	if err != nil {
+		t.Fatalf("NewStory failed: %v", err)
+	}
+	if _, err := story.ContinueMaximally(); err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if !story.State().HasError() {
+		t.Errorf("Expected an error for a string started outside an expression")
+	}
+}
@@ -0,0 +1,21 @@
+package ink
+
+// Tag is the legacy representation of a line tag, written by older compilers
+// as {"#": "text"}. Current compilers emit tags as text enclosed by
+// CommandTypeBeginTag / CommandTypeEndTag, but the runtime still reads both.
+type Tag struct {
+	*BaseRuntimeObject
+	Text string
+}
+
+// NewTag creates a new Tag with the given text.
+func NewTag(text string) *Tag {
+	return &Tag{
+		BaseRuntimeObject: NewBaseRuntimeObject(),
+		Text:              text,
+	}
+}
+
+func (t *Tag) String() string {
+	return "# " + t.Text
+}
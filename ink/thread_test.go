@@ -55,9 +55,9 @@ func TestThreadHubPattern(t *testing.T) {
 	// 4. Thread A output: Thread A.
 	// 5. Thread A done -> Pops.
 	// 6. Main resumes. Main was advanced past threadA Divert?
-	//    Yes, logic implemented in Divert handler advances prevThread.
-	// 7. Main runs. Sees 19 (StartThread). Sets flag.
-	// 8. Main sees Divert(threadB). Forks.
+	//    Yes, stepping on after popping the thread skips the divert.
+	// 7. Main runs. Sees 19 (StartThread). Forks at the threadB Divert.
+	// 8. Fork follows Divert(threadB).
 	// 9. Thread B runs. Output: Thread B.
 	// 10. Thread B done -> Pops.
 	// 11. Main resumes. Advanced past threadB Divert.
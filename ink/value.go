@@ -207,10 +207,7 @@ func CreateValue(val any) Value {
 	case string:
 		return NewStringValue(v)
 	case bool:
-		if v {
-			return NewIntValue(1)
-		}
-		return NewIntValue(0)
+		return NewBoolValue(v)
 	case nil:
 		return nil
 	// TODO: Add other types: *Path, List
@@ -219,3 +216,16 @@ func CreateValue(val any) Value {
 		return NewStringValue(fmt.Sprintf("%v", v))
 	}
 }
+
+// valueToOutputString returns the text written to the output stream when a
+// value is output from an expression.
+func valueToOutputString(obj RuntimeObject) string {
+	if val, ok := obj.(Value); ok {
+		if str, err := val.Cast(ValueTypeString); err == nil && str != nil {
+			if sv, ok := str.(*StringValue); ok {
+				return sv.Value
+			}
+		}
+	}
+	return fmt.Sprintf("%v", obj)
+}
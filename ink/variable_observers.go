@@ -0,0 +1,60 @@
+package ink
+
+import "fmt"
+
+// VariableObserver is called when the value of an observed global variable
+// changes. The new value is converted with RuntimeObjectToNative.
+type VariableObserver func(variableName string, newValue any)
+
+// ObserveVariable registers an observer for changes to the named global
+// variable. Changes made while the story is continuing are batched, so the
+// observer is called at most once per Continue, and only if the value at the
+// end differs from the value at the start.
+func (s *Story) ObserveVariable(variableName string, observer VariableObserver) error {
+	if !s.state.GetVariablesState().GlobalVariableExistsWithName(variableName) {
+		return fmt.Errorf("cannot observe variable '%s' because it wasn't declared in the ink story", variableName)
+	}
+	if s.variableObservers == nil {
+		s.variableObservers = make(map[string][]VariableObserver)
+	}
+	s.variableObservers[variableName] = append(s.variableObservers[variableName], observer)
+	return nil
+}
+
+// ObserveAllVariables registers an observer for changes to any global
+// variable.
+func (s *Story) ObserveAllVariables(observer VariableObserver) {
+	s.allVariableObservers = append(s.allVariableObservers, observer)
+}
+
+// RemoveVariableObserver removes all the observers of the named variable.
+func (s *Story) RemoveVariableObserver(variableName string) {
+	delete(s.variableObservers, variableName)
+}
+
+// RemoveAllVariableObservers removes every variable observer, including
+// those registered with ObserveAllVariables.
+func (s *Story) RemoveAllVariableObservers() {
+	s.variableObservers = nil
+	s.allVariableObservers = nil
+}
+
+// variableStateDidChange notifies the observers of a changed variable.
+func (s *Story) variableStateDidChange(variableName string, newValueObj RuntimeObject) {
+	observers := s.variableObservers[variableName]
+	if len(observers) == 0 && len(s.allVariableObservers) == 0 {
+		return
+	}
+
+	newValue, err := RuntimeObjectToNative(newValueObj)
+	if err != nil {
+		s.state.AddError(fmt.Sprintf("Tried to get the value of a variable that isn't a standard type: %v", err))
+		return
+	}
+	for _, observer := range observers {
+		observer(variableName, newValue)
+	}
+	for _, observer := range s.allVariableObservers {
+		observer(variableName, newValue)
+	}
+}
@@ -0,0 +1,129 @@
+package ink
+
+import "testing"
+
+func TestVariableObserverIgnoresRevertedChanges(t *testing.T) {
+	// VAR x = 0
+	// VAR y = 0
+	// ~ x = 5
+	// ~ x = 0
+	// ~ y = 1
+	// ~ y = 2
+	// done
+	jsonStr := `{"inkVersion":21,"root":[[
+		"ev",5,"/ev",{"VAR=":"x","re":true},
+		"ev",0,"/ev",{"VAR=":"x","re":true},
+		"ev",1,"/ev",{"VAR=":"y","re":true},
+		"ev",2,"/ev",{"VAR=":"y","re":true},
+		"^done","\n","end"],"done",
+		{"global decl":["ev",0,{"VAR=":"x"},0,{"VAR=":"y"},"/ev","end",null]}],"listDefs":{}}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+
+	var changes []string
+	var values []any
+	story.ObserveAllVariables(func(name string, value any) {
+		changes = append(changes, name)
+		values = append(values, value)
+	})
+
+	if _, err := story.Continue(); err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	// x ends the line where it started, and y changed twice but is reported once.
+	if len(changes) != 1 || changes[0] != "y" || values[0] != 2 {
+		t.Errorf("Expected a single change of y to 2, got %v %v", changes, values)
+	}
+}
+
+func TestObserveUndeclaredVariable(t *testing.T) {
+	story, err := NewStory(`{"inkVersion":21,"root":[["done"],"done",null],"listDefs":{}}`)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	if err := story.ObserveVariable("missing", func(string, any) {}); err == nil {
+		t.Errorf("Expected an error when observing an undeclared variable")
+	}
+}
+
+func TestGlobalExistsDuringPatch(t *testing.T) {
+	story, err := NewStory(`{"inkVersion":21,"root":[["done"],"done",null],"listDefs":{}}`)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+
+	// A global only set in the patch, as during lookahead
+	patched := story.State().CopyAndStartPatching()
+	patched.VariablesState.SetGlobal("late", NewIntValue(1))
+	if !patched.VariablesState.GlobalVariableExistsWithName("late") {
+		t.Errorf("Expected the patched global to exist")
+	}
+	if err := story.ObserveVariable("late", func(string, any) {}); err != nil {
+		t.Errorf("Expected to observe the patched global: %v", err)
+	}
+
+	story.State().RestoreAfterPatch()
+	if story.State().VariablesState.GlobalVariableExistsWithName("late") {
+		t.Errorf("Expected the global to be gone once the patch is abandoned")
+	}
+}
+
+func TestVariableObserverWithNestedEvaluateFunction(t *testing.T) {
+	// VAR x = 0
+	// VAR y = 0
+	// EXTERNAL callback()
+	// ~ x = 1
+	// ~ callback()
+	// ~ x = 2
+	// done
+	// === function set_y ===
+	// ~ y = 5
+	jsonStr := `{"inkVersion":21,"root":[[
+		"ev",1,"/ev",{"VAR=":"x","re":true},
+		"ev",{"x()":"callback"},"pop","/ev",
+		"ev",2,"/ev",{"VAR=":"x","re":true},
+		"^done","\n","end"],"done",
+		{"set_y":["ev",5,"/ev",{"VAR=":"y","re":true},"ev","void","/ev","~ret",null],
+		"global decl":["ev",0,{"VAR=":"x"},0,{"VAR=":"y"},"/ev","end",null]}],"listDefs":{}}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+
+	var changes []string
+	var values []any
+	story.ObserveAllVariables(func(name string, value any) {
+		changes = append(changes, name)
+		values = append(values, value)
+	})
+
+	callbackRan := false
+	err = story.BindExternalFunction("callback", func(args []any) (any, error) {
+		callbackRan = true
+		if _, _, err := story.EvaluateFunction("set_y"); err != nil {
+			t.Errorf("EvaluateFunction failed: %v", err)
+		}
+		if len(changes) != 0 {
+			t.Errorf("Expected no notifications during the outer Continue, got %v", changes)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("BindExternalFunction failed: %v", err)
+	}
+
+	if _, err := story.Continue(); err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if !callbackRan {
+		t.Fatalf("Expected the external function to be called")
+	}
+	// Both changes are reported once, when the outer Continue completes
+	if len(changes) != 2 || changes[0] != "x" || values[0] != 2 || changes[1] != "y" || values[1] != 5 {
+		t.Errorf("Expected x to change to 2 and y to 5, got %v %v", changes, values)
+	}
+}
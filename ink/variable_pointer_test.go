@@ -0,0 +1,87 @@
+package ink
+
+import "testing"
+
+func TestRefParameterToGlobal(t *testing.T) {
+	// VAR health = 10
+	// ~ change(ref health, -5)
+	// {health}
+	// == function change(ref x, amount)
+	// ~ x = x + amount
+	jsonStr := `{"inkVersion": 21, "root": [[
+		"ev", {"^var": "health", "ci": -1}, -5, {"f()": "change"}, "pop", "/ev",
+		"ev", {"VAR?": "health"}, "out", "/ev", "\n", "done"], "done", {
+		"change": [{"temp=": "amount"}, {"temp=": "x"},
+			"ev", {"VAR?": "x"}, {"VAR?": "amount"}, "+", "/ev", {"temp=": "x", "re": true},
+			"ev", "void", "/ev", "~ret", null],
+		"global decl": ["ev", 10, {"VAR=": "health"}, "/ev", "end", null]}]}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "5\n" {
+		t.Errorf("Expected %q, got %q", "5\n", text)
+	}
+	if story.State().HasError() {
+		t.Errorf("Unexpected errors: %v", story.State().GetCurrentErrors())
+	}
+}
+
+func TestRefParameterToTemporaryAcrossFrames(t *testing.T) {
+	// {outer()}
+	// == function outer()
+	// ~ temp t = 1
+	// ~ inner(ref t)
+	// ~ return t
+	// == function inner(ref y)
+	// ~ deeper(ref y)
+	// ~ y = y * 2
+	// == function deeper(ref z)
+	// ~ z = z + 10
+	jsonStr := `{"inkVersion": 21, "root": [[
+		"ev", {"f()": "outer"}, "out", "/ev", "\n", "done"], "done", {
+		"outer": ["ev", 1, "/ev", {"temp=": "t"},
+			"ev", {"^var": "t", "ci": -1}, {"f()": "inner"}, "pop", "/ev",
+			"ev", {"VAR?": "t"}, "/ev", "~ret", null],
+		"inner": [{"temp=": "y"},
+			"ev", {"^var": "y", "ci": -1}, {"f()": "deeper"}, "pop", "/ev",
+			"ev", {"VAR?": "y"}, 2, "*", "/ev", {"temp=": "y", "re": true},
+			"ev", "void", "/ev", "~ret", null],
+		"deeper": [{"temp=": "z"},
+			"ev", {"VAR?": "z"}, 10, "+", "/ev", {"temp=": "z", "re": true},
+			"ev", "void", "/ev", "~ret", null]}]}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "22\n" {
+		t.Errorf("Expected %q, got %q", "22\n", text)
+	}
+	if story.State().HasError() {
+		t.Errorf("Unexpected errors: %v", story.State().GetCurrentErrors())
+	}
+}
+
+func TestParseVariablePointer(t *testing.T) {
+	obj, err := JTokenToRuntimeObject(map[string]any{"^var": "health", "ci": float64(0)})
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	ptr, ok := obj.(*VariablePointerValue)
+	if !ok {
+		t.Fatalf("Expected a *VariablePointerValue, got %T", obj)
+	}
+	if ptr.VariableName() != "health" || ptr.ContextIndex() != 0 {
+		t.Errorf("Expected health with context 0, got %s with context %d", ptr.VariableName(), ptr.ContextIndex())
+	}
+}
@@ -9,11 +9,9 @@ type VariablePointerValue struct {
 	contextIndex int // -1 = unknown, 0 = global, 1+ = stack frame
 }
 
-// NewVariablePointerValue creates a new VariablePointerValue.
+// NewVariablePointerValue creates a new VariablePointerValue. Pass -1 as the
+// context index if it isn't known yet.
 func NewVariablePointerValue(variableName string, contextIndex int) *VariablePointerValue {
-	if contextIndex == 0 {
-		contextIndex = -1 // Default to unknown/generic if 0 passed. Java default is -1.
-	}
 	return &VariablePointerValue{
 		BaseRuntimeObject: NewBaseRuntimeObject(),
 		variableName:      variableName,
@@ -0,0 +1,120 @@
+package ink
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// Variables gives the host typed access to the story's global variables.
+// Values are converted with RuntimeObjectToNative, so ints, floats, strings
+// and bools come back as Go values, lists as *List and divert targets as
+// path strings.
+type Variables struct {
+	story *Story
+}
+
+// Variables returns a view of the story's global variables.
+func (s *Story) Variables() *Variables {
+	return &Variables{story: s}
+}
+
+// Get returns the value of a global variable, and whether it is declared.
+func (v *Variables) Get(name string) (any, bool) {
+	obj, ok := v.story.state.GetVariablesState().globalValue(name)
+	if !ok {
+		return nil, false
+	}
+	val, err := RuntimeObjectToNative(obj)
+	if err != nil {
+		return nil, false
+	}
+	return val, true
+}
+
+// Set assigns a global variable. The variable must be declared in the ink
+// story, and the new value must have the same ink type as the current one.
+// A divert target can be set from a path string.
+func (v *Variables) Set(name string, val any) error {
+	vs := v.story.state.GetVariablesState()
+	oldValue, ok := vs.globalValue(name)
+	if !ok {
+		return fmt.Errorf("cannot assign to a variable (%s) that hasn't been declared in the story", name)
+	}
+
+	var newValue RuntimeObject
+	if path, isString := val.(string); isString && isDivertTarget(oldValue) {
+		target := NewPathFromString(path)
+		if v.story.MainContent.ContentAtPath(target) == nil {
+			return fmt.Errorf("cannot set '%s' to a divert target that doesn't exist: %s", name, path)
+		}
+		newValue = NewDivertTargetValue(target)
+	} else {
+		obj, err := NativeToRuntimeObject(val)
+		if err != nil {
+			return fmt.Errorf("cannot set '%s': %w", name, err)
+		}
+		if obj == nil {
+			return fmt.Errorf("cannot set '%s' to nil", name)
+		}
+		newValue = obj
+	}
+
+	if valueTypeOf(oldValue) != valueTypeOf(newValue) {
+		oldNative, _ := RuntimeObjectToNative(oldValue)
+		return fmt.Errorf("cannot change the type of '%s' from %T to %T", name, oldNative, val)
+	}
+
+	vs.SetGlobal(name, newValue)
+	return nil
+}
+
+// All iterates over every declared global variable, in name order.
+func (v *Variables) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		vs := v.story.state.GetVariablesState()
+		names := make([]string, 0, len(vs.GlobalVariables))
+		for name := range vs.GlobalVariables {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			val, ok := v.Get(name)
+			if !ok {
+				continue
+			}
+			if !yield(name, val) {
+				return
+			}
+		}
+	}
+}
+
+// GetAs returns the value of a global variable as a T, or an error if the
+// variable isn't declared or holds a different type.
+func GetAs[T any](v *Variables, name string) (T, error) {
+	var zero T
+	val, ok := v.Get(name)
+	if !ok {
+		return zero, fmt.Errorf("variable '%s' is not declared in the story", name)
+	}
+	typed, ok := val.(T)
+	if !ok {
+		return zero, fmt.Errorf("variable '%s' is a %T, not a %T", name, val, zero)
+	}
+	return typed, nil
+}
+
+func isDivertTarget(obj RuntimeObject) bool {
+	_, ok := obj.(*DivertTargetValue)
+	return ok
+}
+
+// valueTypeOf returns the ink type of a variable value.
+func valueTypeOf(obj RuntimeObject) ValueType {
+	if val, ok := obj.(Value); ok {
+		return val.GetValueType()
+	}
+	return ValueTypeNoType
+}
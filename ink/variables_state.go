@@ -1,5 +1,10 @@
 package ink
 
+import (
+	"maps"
+	"slices"
+)
+
 // VariableChangedFunc is a callback for variable changes.
 type VariableChangedFunc func(variableName string, newValue RuntimeObject)
 
@@ -12,7 +17,7 @@ type VariablesState struct {
 	Patch                  *StatePatch
 
 	batchObservingVariableChanges bool
-	changedVariablesForBatchObs   map[string]struct{}
+	changedVariablesForBatchObs   map[string]RuntimeObject // Value before the first change in the batch
 	variableChangedEvent          VariableChangedFunc
 }
 
@@ -25,11 +30,50 @@ func NewVariablesState(callStack *CallStack, listDefsOrigin *ListDefinitionsOrig
 	}
 }
 
+// SnapshotDefaultGlobals records the current globals as their default
+// values. Globals that still have their default value aren't saved.
+func (vs *VariablesState) SnapshotDefaultGlobals() {
+	vs.DefaultGlobalVariables = maps.Clone(vs.GlobalVariables)
+}
+
 // SetCallStack sets the call stack.
 func (vs *VariablesState) SetCallStack(callStack *CallStack) {
 	vs.CallStack = callStack
 }
 
+// StartVariableObservation starts batching variable change notifications,
+// so that each changed variable is reported once, when the batch completes.
+func (vs *VariablesState) StartVariableObservation() {
+	vs.batchObservingVariableChanges = true
+	vs.changedVariablesForBatchObs = make(map[string]RuntimeObject)
+}
+
+// CompleteVariableObservation ends the batch started by
+// StartVariableObservation and sends a notification for every variable whose
+// value differs from its value at the start of the batch.
+func (vs *VariablesState) CompleteVariableObservation() {
+	changed := vs.changedVariablesForBatchObs
+	vs.batchObservingVariableChanges = false
+	vs.changedVariablesForBatchObs = nil
+
+	if vs.variableChangedEvent == nil {
+		return
+	}
+
+	names := make([]string, 0, len(changed))
+	for name := range changed {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		currentValue := vs.GlobalVariables[name]
+		if !runtimeValuesEqual(changed[name], currentValue) {
+			vs.variableChangedEvent(name, currentValue)
+		}
+	}
+}
+
 // Assign assigns a value to a variable, handling global/temporary and pointers.
 func (vs *VariablesState) Assign(varAss *VariableAssignment, value RuntimeObject) error {
 	name := varAss.VariableName()
@@ -44,8 +88,6 @@ func (vs *VariablesState) Assign(varAss *VariableAssignment, value RuntimeObject
 		// Fallback for loose JSON/Testing:
 		// If variable doesn't exist, but assignment implies Key "VAR=" (Global), create it as global.
 		if !setGlobal && varAss.IsGlobal() {
-			// Check if it exists as local?
-			// For now, assume global preference if VAR= is used.
 			setGlobal = true
 		}
 	}
@@ -87,22 +129,43 @@ func (vs *VariablesState) Assign(varAss *VariableAssignment, value RuntimeObject
 
 // SetGlobal sets a global variable.
 func (vs *VariablesState) SetGlobal(name string, value RuntimeObject) {
-	oldValue, exists := vs.GlobalVariables[name]
+	oldValue, exists := vs.globalValue(name)
 
-	// TODO: ListValue logic (retain list origin)
+	retainListOriginsForAssignment(oldValue, value)
 
-	vs.GlobalVariables[name] = value
+	if vs.Patch != nil {
+		vs.Patch.Globals[name] = value
+	} else {
+		vs.GlobalVariables[name] = value
+	}
 
-	if exists && oldValue != value { // TODO: Value equality check?
-		if vs.variableChangedEvent != nil {
-			vs.variableChangedEvent(name, value)
+	if vs.variableChangedEvent == nil || !exists {
+		return
+	}
+	if vs.batchObservingVariableChanges {
+		if vs.Patch != nil {
+			vs.Patch.ChangedVariables[name] = struct{}{}
+			return
 		}
-		if vs.batchObservingVariableChanges {
-			if vs.changedVariablesForBatchObs != nil {
-				vs.changedVariablesForBatchObs[name] = struct{}{}
-			}
+		// Remember the value from before the batch, to check at the end
+		// whether it really changed
+		if _, seen := vs.changedVariablesForBatchObs[name]; !seen {
+			vs.changedVariablesForBatchObs[name] = oldValue
+		}
+	} else if !runtimeValuesEqual(oldValue, value) {
+		vs.variableChangedEvent(name, value)
+	}
+}
+
+// ApplyPatch commits the globals changed in the patch.
+func (vs *VariablesState) ApplyPatch() {
+	for name := range vs.Patch.ChangedVariables {
+		if _, seen := vs.changedVariablesForBatchObs[name]; !seen && vs.batchObservingVariableChanges {
+			vs.changedVariablesForBatchObs[name] = vs.GlobalVariables[name]
 		}
 	}
+	maps.Copy(vs.GlobalVariables, vs.Patch.Globals)
+	vs.Patch = nil
 }
 
 // GetVariableWithName gets a variable value.
@@ -114,46 +177,52 @@ func (vs *VariablesState) GetVariableWithName(name string) RuntimeObject {
 func (vs *VariablesState) GetVariableWithNameContext(name string, contextIndex int) RuntimeObject {
 	varValue := vs.GetRawVariableWithName(name, contextIndex)
 
+	// Get value from pointer?
 	if varPtr, ok := varValue.(*VariablePointerValue); ok {
-		resolved, _ := vs.ResolveVariablePointer(varPtr)
-		return resolved
+		return vs.GetVariableWithNameContext(varPtr.VariableName(), varPtr.ContextIndex())
 	}
 
 	return varValue
 }
 
-// GetRawVariableWithName gets the raw object (potentially a pointer).
+// GetRawVariableWithName gets the raw object (potentially a pointer). A
+// context index of 0 looks up globals only, and -1 looks up globals and then
+// the temporaries of the current scope.
 func (vs *VariablesState) GetRawVariableWithName(name string, contextIndex int) RuntimeObject {
-	// 1. Context / Temp variables (Local Scope)
-	varValue := vs.CallStack.GetTemporaryVariableWithName(name, contextIndex)
-	if varValue != nil {
-		return varValue
-	}
-
-	// 2. Globals (if allowed)
+	// 0 context = global
 	if contextIndex == 0 || contextIndex == -1 {
-		if vs.Patch != nil {
-			if val, ok := vs.Patch.GetGlobals()[name]; ok {
-				return val
-			}
-		}
-
-		if val, ok := vs.GlobalVariables[name]; ok {
+		if val, ok := vs.globalValue(name); ok {
 			return val
 		}
 
-		// if vs.ListDefsOrigin != nil {
-		// TODO: List definitions access
-		// }
+		// Bare list item names, e.g. {Happy} or {Mood.Happy}
+		if vs.ListDefsOrigin != nil {
+			if listItemValue := vs.ListDefsOrigin.FindSingleItemListWithName(name); listItemValue != nil {
+				return listItemValue
+			}
+		}
 	}
 
-	return nil
+	// Temporary
+	return vs.CallStack.GetTemporaryVariableWithName(name, contextIndex)
 }
 
-// GlobalVariableExistsWithName checks if a global variable exists.
+// globalValue returns the current value of a global variable, including any
+// uncommitted change in the patch.
+func (vs *VariablesState) globalValue(name string) (RuntimeObject, bool) {
+	if vs.Patch != nil {
+		if val, ok := vs.Patch.GetGlobals()[name]; ok {
+			return val, true
+		}
+	}
+	val, ok := vs.GlobalVariables[name]
+	return val, ok
+}
+
+// GlobalVariableExistsWithName checks if a global variable exists,
+// including one only set so far in the patch.
 func (vs *VariablesState) GlobalVariableExistsWithName(name string) bool {
-	_, ok := vs.GlobalVariables[name]
-	// TODO: Check patches?
+	_, ok := vs.globalValue(name)
 	return ok
 }
 
@@ -167,11 +236,12 @@ func (vs *VariablesState) ResolveVariablePointer(varPtr *VariablePointerValue) (
 
 	value := vs.GetRawVariableWithName(varPtr.VariableName(), contextIndex)
 
-	if nextPtr, ok := value.(*VariablePointerValue); ok {
-		return vs.ResolveVariablePointer(nextPtr)
+	// Extra layer of indirection: if the variable we're pointing to is also
+	// a pointer, point at what it points to instead
+	if doubleRedirectionPointer, ok := value.(*VariablePointerValue); ok {
+		return doubleRedirectionPointer, nil
 	}
 
-	// Ensure we store the context index
 	return NewVariablePointerValue(varPtr.VariableName(), contextIndex), nil
 }
 
@@ -197,6 +267,38 @@ func (vs *VariablesState) Copy(newCallStack *CallStack) *VariablesState {
 		cp.GlobalVariables[k] = v // Shallow copy of map value (pointer)
 		// If v is mutable, we might need v.Copy().
 	}
-	// TODO: Default globals, patch, etc.
+	// Defaults are only ever replaced as a whole, so can be shared
+	cp.DefaultGlobalVariables = vs.DefaultGlobalVariables
+	// TODO: patch, etc.
 	return cp
 }
+
+// runtimeValuesEqual reports whether two variable values are the same ink
+// value, as opposed to the same object.
+func runtimeValuesEqual(a, b RuntimeObject) bool {
+	switch av := a.(type) {
+	case *ListValue:
+		bv, ok := b.(*ListValue)
+		return ok && av.Value.Equals(bv.Value)
+	case *DivertTargetValue:
+		bv, ok := b.(*DivertTargetValue)
+		return ok && av.GetTargetPath().String() == bv.GetTargetPath().String()
+	case *VariablePointerValue:
+		bv, ok := b.(*VariablePointerValue)
+		return ok && av.VariableName() == bv.VariableName() && av.ContextIndex() == bv.ContextIndex()
+	case Value:
+		bv, ok := b.(Value)
+		return ok && av.GetValueType() == bv.GetValueType() && av.GetValueObject() == bv.GetValueObject()
+	}
+	return a == b
+}
+
+// retainListOriginsForAssignment keeps the origins of a list variable when it
+// is assigned the empty list, so that e.g. LIST_ALL still works on it.
+func retainListOriginsForAssignment(oldValue, newValue RuntimeObject) {
+	oldList, oldOk := oldValue.(*ListValue)
+	newList, newOk := newValue.(*ListValue)
+	if oldOk && newOk && len(newList.Value.Items) == 0 {
+		newList.Value.SetInitialOriginNames(oldList.Value.OriginNames())
+	}
+}
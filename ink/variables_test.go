@@ -0,0 +1,81 @@
+package ink
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestVariablesGetSet(t *testing.T) {
+	// LIST colours = red, (green)
+	// VAR n = 1
+	// VAR flag = true
+	// VAR target = -> knot
+	// {n} {flag} {colours}
+	jsonStr := `{"inkVersion":21,"root":[[
+		"ev",{"VAR?":"n"},"out","/ev","^ ","ev",{"VAR?":"flag"},"out","/ev","^ ","ev",{"VAR?":"colours"},"out","/ev","\n","done"],
+		"done",{"knot":["^knot","\n","done",null],
+		"global decl":["ev",{"list":{"colours.green":2}},{"VAR=":"colours"},1,{"VAR=":"n"},true,{"VAR=":"flag"},{"^->":"knot"},{"VAR=":"target"},"/ev","end",null]}],
+		"listDefs":{"colours":{"red":1,"green":2}}}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	vars := story.Variables()
+
+	if n, err := GetAs[int](vars, "n"); err != nil || n != 1 {
+		t.Errorf("GetAs[int](n) = %v, %v", n, err)
+	}
+	if _, err := GetAs[string](vars, "n"); err == nil {
+		t.Errorf("Expected an error reading an int as a string")
+	}
+	if target, ok := vars.Get("target"); !ok || target != "knot" {
+		t.Errorf("Get(target) = %v, %v", target, ok)
+	}
+	if _, ok := vars.Get("missing"); ok {
+		t.Errorf("Expected an undeclared variable to be missing")
+	}
+
+	if err := vars.Set("n", 7); err != nil {
+		t.Fatalf("Set(n) failed: %v", err)
+	}
+	if err := vars.Set("flag", false); err != nil {
+		t.Fatalf("Set(flag) failed: %v", err)
+	}
+	red := NewList()
+	red.Add(NewListItem("colours", "red"), 1)
+	if err := vars.Set("colours", red); err != nil {
+		t.Fatalf("Set(colours) failed: %v", err)
+	}
+	if err := vars.Set("target", "knot"); err != nil {
+		t.Errorf("Set(target) failed: %v", err)
+	}
+
+	errorCases := map[string]any{
+		"missing": 1,
+		"n":       "seven",
+		"flag":    1,
+		"target":  "no_such_knot",
+	}
+	for name, val := range errorCases {
+		if err := vars.Set(name, val); err == nil {
+			t.Errorf("Expected an error setting %s to %v", name, val)
+		}
+	}
+
+	text, err := story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "7 false red\n" {
+		t.Errorf("Expected %q, got %q", "7 false red\n", text)
+	}
+
+	var names []string
+	for name := range vars.All() {
+		names = append(names, name)
+	}
+	if want := []string{"colours", "flag", "n", "target"}; !slices.Equal(names, want) {
+		t.Errorf("All() gave %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,119 @@
+package ink
+
+import "testing"
+
+func TestReadCountsAndTurns(t *testing.T) {
+	// {0} {TURNS_SINCE(-> 0)} {READ_COUNT(-> 0)}
+	// * [next] {TURNS()}
+	jsonStr := `{"root": [[
+		"ev", {"CNT?": ".^"}, "out", "/ev", "^ ",
+		"ev", {"^->": "0"}, "turns", "out", "/ev", "^ ",
+		"ev", {"^->": "0"}, "readc", "out", "/ev", "\n",
+		"ev", "str", "^next", "/str", "/ev", {"*": ".^.c-0", "flg": 20},
+		{"c-0": ["ev", "turn", "out", "/ev", "\n", "done", {"#f": 5}], "#f": 3}], "done", null], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "1 0 1\n" {
+		t.Errorf("Expected %q, got %q", "1 0 1\n", text)
+	}
+
+	if err := story.ChooseChoiceIndex(0); err != nil {
+		t.Fatalf("Choose failed: %v", err)
+	}
+	text, err = story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "1\n" {
+		t.Errorf("Expected TURNS() to be %q after one choice, got %q", "1\n", text)
+	}
+
+	if count := story.State().VisitCountAtPathString("0"); count != 1 {
+		t.Errorf("Expected visit count 1, got %d", count)
+	}
+}
+
+func TestReadCountsAfterDivert(t *testing.T) {
+	// === knot ===
+	// {knot} {TURNS_SINCE(-> knot)} {TURNS()} {READ_COUNT(-> knot)}
+	// + [again] -> knot
+	// The knot counts visits at start only, so looping back to it from its own
+	// choice still counts as a new visit.
+	jsonStr := `{"root": [{"->": "knot"}, "done", {"knot": [
+		"ev", {"CNT?": ".^"}, "out", "/ev", "^ ",
+		"ev", {"^->": "knot"}, "turns", "out", "/ev", "^ ",
+		"ev", "turn", "out", "/ev", "^ ",
+		"ev", {"^->": "knot"}, "readc", "out", "/ev", "\n",
+		"ev", "str", "^again", "/str", "/ev", {"*": ".^.c-0", "flg": 4},
+		{"c-0": [{"->": "knot"}, {"#f": 5}], "#f": 7}]}], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+
+	expected := []string{"1 0 0 1\n", "2 0 1 2\n", "3 0 2 3\n"}
+	for i, want := range expected {
+		if i > 0 {
+			if err := story.ChooseChoiceIndex(0); err != nil {
+				t.Fatalf("Choose failed: %v", err)
+			}
+		}
+		text, err := story.ContinueMaximally()
+		if err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		if text != want {
+			t.Errorf("Turn %d: expected %q, got %q", i, want, text)
+		}
+	}
+
+	if count := story.State().VisitCountAtPathString("knot"); count != 3 {
+		t.Errorf("Expected knot visit count 3, got %d", count)
+	}
+}
+
+func TestTurnsSinceUnvisited(t *testing.T) {
+	// {TURNS_SINCE(-> other)}
+	jsonStr := `{"root": [["ev", {"^->": "other"}, "turns", "out", "/ev", "\n", "done"], "done", {"other": ["done", {"#f": 3}]}], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	text, err := story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "-1\n" {
+		t.Errorf("Expected %q, got %q", "-1\n", text)
+	}
+}
+
+func TestReadCountWithoutDivertTarget(t *testing.T) {
+	// {TURNS_SINCE(3)} {READ_COUNT(3)}
+	jsonStr := `{"root": [["ev", 3, "turns", "out", "/ev", "^ ", "ev", 3, "readc", "out", "/ev", "\n", "done"], "done", null], "inkVersion": 21}`
+
+	story, err := NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	text, err := story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "-1 0\n" {
+		t.Errorf("Expected %q, got %q", "-1 0\n", text)
+	}
+	if errs := story.State().GetCurrentErrors(); len(errs) != 2 {
+		t.Errorf("Expected 2 errors, got %v", errs)
+	}
+}
@@ -0,0 +1,112 @@
+package test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/samdammers/ink-go/ink"
+)
+
+// choiceTexts returns the text of each choice currently offered by the story.
+func choiceTexts(story *ink.Story) []string {
+	var texts []string
+	for _, c := range story.GetCurrentChoices() {
+		texts = append(texts, c.Text)
+	}
+	return texts
+}
+
+func TestFallbackChoice(t *testing.T) {
+	story := loadStory(t, "choices/fallback-choice.ink.json")
+
+	expected := [][]string{
+		{"The woman in the hat?", "The man with the briefcase?"},
+		{"The man with the briefcase?"},
+	}
+	for i, want := range expected {
+		if _, err := story.ContinueMaximally(); err != nil {
+			t.Fatalf("ContinueMaximally failed: %v", err)
+		}
+		if got := choiceTexts(story); !slices.Equal(got, want) {
+			t.Fatalf("Turn %d: got choices %q, want %q", i, got, want)
+		}
+		if err := story.ChooseChoiceIndex(0); err != nil {
+			t.Fatalf("ChooseChoiceIndex failed: %v", err)
+		}
+	}
+
+	// With both once-only choices used up, the invisible default is taken.
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if !strings.HasSuffix(text, "But it is too late: you collapse onto the station platform. This is the end.\n") {
+		t.Errorf("Got %q", text)
+	}
+	if len(story.GetCurrentChoices()) != 0 {
+		t.Errorf("Expected the fallback choice to be hidden, got %q", choiceTexts(story))
+	}
+}
+
+func TestStickyChoice(t *testing.T) {
+	story := loadStory(t, "choices/sticky-choice.ink.json")
+	want := []string{"Eat another donut", "Get off the couch"}
+
+	for i := 0; i < 3; i++ {
+		text, err := story.ContinueMaximally()
+		if err != nil {
+			t.Fatalf("ContinueMaximally failed: %v", err)
+		}
+		if i > 0 && strings.TrimSpace(text) != "You eat another donut." {
+			t.Errorf("Turn %d: got %q", i, text)
+		}
+		if got := choiceTexts(story); !slices.Equal(got, want) {
+			t.Fatalf("Turn %d: got choices %q, want %q", i, got, want)
+		}
+		if err := story.ChooseChoiceIndex(0); err != nil {
+			t.Fatalf("ChooseChoiceIndex failed: %v", err)
+		}
+	}
+
+	if _, err := story.ContinueMaximally(); err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if err := story.ChooseChoiceIndex(1); err != nil {
+		t.Fatalf("ChooseChoiceIndex failed: %v", err)
+	}
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "You struggle up off the couch to go and compose epic poetry.\n" {
+		t.Errorf("Got %q", text)
+	}
+}
+
+func TestSuppressChoice(t *testing.T) {
+	story := loadStory(t, "choices/suppress-choice.ink.json")
+
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "Hello world!\n" {
+		t.Errorf("Got %q", text)
+	}
+	if want := []string{"Hello back!"}; !slices.Equal(choiceTexts(story), want) {
+		t.Fatalf("Got choices %q, want %q", choiceTexts(story), want)
+	}
+
+	if err := story.ChooseChoiceIndex(0); err != nil {
+		t.Fatalf("ChooseChoiceIndex failed: %v", err)
+	}
+	text, err = story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	// The bracketed choice text is not echoed into the output.
+	if text != "Nice to hear from you.\n" {
+		t.Errorf("Got %q", text)
+	}
+}
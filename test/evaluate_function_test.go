@@ -0,0 +1,36 @@
+package test
+
+import "testing"
+
+func TestEvaluateFunctionDuringTunnel(t *testing.T) {
+	story := loadStory(t, "function/evaluating-function-variablestate-bug.ink.json")
+
+	for _, want := range []string{"Start\n", "In tunnel.\n"} {
+		text, err := story.Continue()
+		if err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		if text != want {
+			t.Errorf("Got %q, want %q", text, want)
+		}
+	}
+
+	result, output, err := story.EvaluateFunction("function_to_evaluate")
+	if err != nil {
+		t.Fatalf("EvaluateFunction failed: %v", err)
+	}
+	if result != "RIGHT" {
+		t.Errorf("Got result %v", result)
+	}
+	if output != "" {
+		t.Errorf("Got output %q", output)
+	}
+
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "End\n" {
+		t.Errorf("Got %q after evaluating the function", text)
+	}
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExternalFunctions(t *testing.T) {
+	cases := []struct {
+		File     string
+		Expected string
+	}{
+		{File: "external-function-0-arg.ink.json", Expected: "The value is 0 args.\n"},
+		{File: "external-function-1-arg.ink.json", Expected: "The value is [1].\n"},
+		{File: "external-function-2-arg.ink.json", Expected: "The value is [3 4].\n"},
+		{File: "external-function-3-arg.ink.json", Expected: "The value is [1 2 3].\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.File, func(t *testing.T) {
+			story := loadStory(t, "runtime/"+tc.File)
+			err := story.BindExternalFunction("externalFunction", func(args []any) (any, error) {
+				if len(args) == 0 {
+					return "0 args", nil
+				}
+				return fmt.Sprint(args), nil
+			})
+			if err != nil {
+				t.Fatalf("BindExternalFunction failed: %v", err)
+			}
+
+			text, err := story.ContinueMaximally()
+			if err != nil {
+				t.Fatalf("ContinueMaximally failed: %v", err)
+			}
+			if text != tc.Expected {
+				t.Errorf("Got %q, want %q", text, tc.Expected)
+			}
+			if story.State().HasError() {
+				t.Errorf("Unexpected errors: %v", story.State().GetCurrentErrors())
+			}
+		})
+	}
+}
+
+func TestExternalFunctionFallback(t *testing.T) {
+	story := loadStory(t, "runtime/external-function-2-arg.ink.json")
+	story.AllowExternalFunctionFallbacks = true
+
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "The value is 7.\n" {
+		t.Errorf("Got %q", text)
+	}
+	if story.State().HasError() {
+		t.Errorf("Unexpected errors: %v", story.State().GetCurrentErrors())
+	}
+}
+
+func TestUnboundExternalFunctionWithoutFallback(t *testing.T) {
+	story := loadStory(t, "runtime/external-function-2-arg.ink.json")
+
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "The value is .\n" {
+		t.Errorf("Got %q", text)
+	}
+	errs := story.State().GetCurrentErrors()
+	want := "trying to call EXTERNAL function 'externalFunction' which has not been defined (and ink fallbacks disabled)"
+	if len(errs) != 1 || errs[0] != want {
+		t.Errorf("Got errors %q", errs)
+	}
+}
@@ -0,0 +1,141 @@
+package test
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMultiFlowBasics(t *testing.T) {
+	story := loadStory(t, "runtime/multiflow-basics.ink.json")
+
+	steps := []struct {
+		Flow     string
+		Path     string
+		Expected string
+	}{
+		{Flow: "First", Path: "knot1", Expected: "knot 1 line 1\n"},
+		{Flow: "Second", Path: "knot2", Expected: "knot 2 line 1\n"},
+		{Flow: "First", Expected: "knot 1 line 2\n"},
+		{Flow: "Second", Expected: "knot 2 line 2\n"},
+	}
+	for _, step := range steps {
+		if err := story.SwitchFlow(step.Flow); err != nil {
+			t.Fatalf("SwitchFlow failed: %v", err)
+		}
+		if step.Path != "" {
+			if err := story.ChoosePathString(step.Path); err != nil {
+				t.Fatalf("ChoosePathString failed: %v", err)
+			}
+		}
+		text, err := story.Continue()
+		if err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		if text != step.Expected {
+			t.Errorf("%s: got %q, want %q", step.Flow, text, step.Expected)
+		}
+	}
+
+	if want := []string{"First", "Second"}; !slices.Equal(story.AliveFlowNames(), want) {
+		t.Errorf("Got alive flows %q, want %q", story.AliveFlowNames(), want)
+	}
+	if story.CurrentFlowName() != "Second" || story.CurrentFlowIsDefaultFlow() {
+		t.Errorf("Expected to be in flow Second, got %q", story.CurrentFlowName())
+	}
+}
+
+func TestMultiFlowSaveLoadThreads(t *testing.T) {
+	story := loadStory(t, "runtime/multiflow-saveloadthreads.ink.json")
+
+	text, err := story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "Default line 1\n" {
+		t.Errorf("Got %q", text)
+	}
+
+	for _, flow := range []string{"blue", "red"} {
+		if err := story.SwitchFlow(flow + " flow"); err != nil {
+			t.Fatalf("SwitchFlow failed: %v", err)
+		}
+		if err := story.ChoosePathString(flow); err != nil {
+			t.Fatalf("ChoosePathString failed: %v", err)
+		}
+		text, err := story.ContinueMaximally()
+		if err != nil {
+			t.Fatalf("ContinueMaximally failed: %v", err)
+		}
+		if want := "Hello I'm " + flow + "\n"; text != want {
+			t.Errorf("Got %q, want %q", text, want)
+		}
+	}
+
+	// Each flow keeps its own choices
+	for _, flow := range []string{"blue", "red"} {
+		if err := story.SwitchFlow(flow + " flow"); err != nil {
+			t.Fatalf("SwitchFlow failed: %v", err)
+		}
+		want := []string{"Thread 1 " + flow + " choice", "Thread 2 " + flow + " choice"}
+		if got := choiceTexts(story); !slices.Equal(got, want) {
+			t.Errorf("Got choices %q, want %q", got, want)
+		}
+	}
+
+	saved, err := story.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+
+	cases := []struct {
+		Flow     string
+		Choice   int
+		Expected string
+	}{
+		{Flow: "red flow", Choice: 0, Expected: "Thread 1 red choice\nAfter thread 1 choice (red)\n"},
+		{Flow: "red flow", Choice: 1, Expected: "Thread 2 red choice\nAfter thread 2 choice (red)\n"},
+		{Flow: "blue flow", Choice: 0, Expected: "Thread 1 blue choice\nAfter thread 1 choice (blue)\n"},
+		{Flow: "blue flow", Choice: 1, Expected: "Thread 2 blue choice\nAfter thread 2 choice (blue)\n"},
+	}
+	for _, tc := range cases {
+		restored := loadStory(t, "runtime/multiflow-saveloadthreads.ink.json")
+		if err := restored.LoadState(saved); err != nil {
+			t.Fatalf("LoadState failed: %v", err)
+		}
+		if restored.CurrentFlowName() != "red flow" {
+			t.Errorf("Expected to load into red flow, got %q", restored.CurrentFlowName())
+		}
+		if err := restored.SwitchFlow(tc.Flow); err != nil {
+			t.Fatalf("SwitchFlow failed: %v", err)
+		}
+		if err := restored.ChooseChoiceIndex(tc.Choice); err != nil {
+			t.Fatalf("ChooseChoiceIndex failed: %v", err)
+		}
+		text, err := restored.ContinueMaximally()
+		if err != nil {
+			t.Fatalf("ContinueMaximally failed: %v", err)
+		}
+		if text != tc.Expected {
+			t.Errorf("%s choice %d: got %q, want %q", tc.Flow, tc.Choice, text, tc.Expected)
+		}
+
+		// Removing the active flow reverts to the default flow
+		if err := restored.RemoveFlow(tc.Flow); err != nil {
+			t.Fatalf("RemoveFlow failed: %v", err)
+		}
+		if !restored.CurrentFlowIsDefaultFlow() {
+			t.Errorf("Expected the default flow after removing %q", tc.Flow)
+		}
+		text, err = restored.Continue()
+		if err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		if text != "Default line 2\n" {
+			t.Errorf("Got %q from the default flow", text)
+		}
+	}
+
+	if err := story.RemoveFlow("DEFAULT_FLOW"); err == nil {
+		t.Errorf("Expected an error removing the default flow")
+	}
+}
@@ -0,0 +1,34 @@
+package test
+
+import "testing"
+
+func TestFunctionFixtures(t *testing.T) {
+	cases := []struct {
+		File     string
+		Expected string
+	}{
+		{File: "function/func-none.ink.json", Expected: "The value of x is 3.8.\n"},
+		{File: "function/func-basic.ink.json", Expected: "The value of x is 4.4.\n"},
+		{File: "function/func-inline.ink.json", Expected: "The value of x is 4.4.\n"},
+		{File: "function/setvar-func.ink.json", Expected: "The value is 6.\n"},
+		{File: "function/complex-func1.ink.json", Expected: "The values are 6 and 10.\n"},
+		{File: "function/complex-func2.ink.json", Expected: "The values are -1 and 0 and 1.\n"},
+		{
+			File:     "function/complex-func3.ink.json",
+			Expected: "\"I will pay you 120 reales if you get the goods to their destination. The goods will take up 20 cargo spaces.\"\n",
+		},
+	}
+	for _, tc := range cases {
+		story := loadStory(t, tc.File)
+		text, err := story.ContinueMaximally()
+		if err != nil {
+			t.Fatalf("%s: ContinueMaximally failed: %v", tc.File, err)
+		}
+		if story.State().HasError() {
+			t.Fatalf("%s: story errors: %v", tc.File, story.State().GetCurrentErrors())
+		}
+		if text != tc.Expected {
+			t.Errorf("%s: got %q, want %q", tc.File, text, tc.Expected)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package test
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGlueLineByLine(t *testing.T) {
+	cases := []struct {
+		File     string
+		Expected []string
+	}{
+		{File: "glue-with-divert.ink.json", Expected: []string{"We hurried home to Savile Row as fast as we could.\n"}},
+		{File: "left-right-glue-matching.ink.json", Expected: []string{"A line.\n", "Another line.\n"}},
+		{File: "simple-glue.ink.json", Expected: []string{"Some content with glue.\n"}},
+		{File: "testbugfix1.ink.json", Expected: []string{"A\n", "C\n"}},
+		{File: "testbugfix2.ink.json", Expected: []string{"A\n", "X\n"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.File, func(t *testing.T) {
+			story := loadStory(t, "glue/"+tc.File)
+
+			// Each Continue returns exactly one line, with glue already applied
+			var lines []string
+			for story.CanContinue() {
+				text, err := story.Continue()
+				if err != nil {
+					t.Fatalf("Continue failed: %v", err)
+				}
+				lines = append(lines, text)
+			}
+			if !slices.Equal(lines, tc.Expected) {
+				t.Errorf("Got lines %q, want %q", lines, tc.Expected)
+			}
+		})
+	}
+}
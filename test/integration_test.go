@@ -74,8 +74,8 @@ func TestVictoryLap(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Runtime Error at Step 1: %v", err)
 	}
-	if text != "Start. \n" {
-		t.Errorf("Step 1 Fail: Expected 'Start. \\n', got '%s'", text)
+	if text != "Start.\n" {
+		t.Errorf("Step 1 Fail: Expected 'Start.\\n', got '%s'", text)
 	}
 
 	// Step 2: Inside Tunnel (The "Orphaned List" Check)
@@ -94,8 +94,8 @@ func TestVictoryLap(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Runtime Error at Step 3: %v", err)
 	}
-	if text != "End. " {
-		t.Errorf("Step 3 Fail: Expected 'End. ', got '%s'", text)
+	if text != "End." {
+		t.Errorf("Step 3 Fail: Expected 'End.', got '%s'", text)
 	}
 
 	// 4. Final Sanity Check
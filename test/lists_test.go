@@ -0,0 +1,121 @@
+package test
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListFixtures(t *testing.T) {
+	cases := []struct {
+		File     string
+		Expected string
+	}{
+		{File: "lists/basic-operations.ink.json", Expected: "b, d\na, b, c, e\nb, c\nfalse\ntrue\ntrue\n"},
+		{File: "lists/list-mixed-items.ink.json", Expected: "a, y, c\n"},
+		{File: "lists/list-all.ink.json", Expected: "A, B\n"},
+		{File: "lists/empty-list-origin.ink.json", Expected: "a, b\n"},
+		{File: "lists/empty-list-origin-after-assignment.ink.json", Expected: "a, b, c\n"},
+		{File: "lists/more-list-operations.ink.json", Expected: "1\nl\nn\nl, m\nn\n"},
+		{
+			File:     "lists/list-range.ink.json",
+			Expected: "Pound, Pizza, Euro, Pasta, Dollar, Curry, Paella\nEuro, Pasta, Dollar, Curry\nTwo, Three, Four, Five, Six\nPizza, Pasta\n",
+		},
+	}
+	for _, tc := range cases {
+		story := loadStory(t, tc.File)
+		text, err := story.ContinueMaximally()
+		if err != nil {
+			t.Fatalf("%s: ContinueMaximally failed: %v", tc.File, err)
+		}
+		if story.State().HasError() {
+			t.Fatalf("%s: story errors: %v", tc.File, story.State().GetCurrentErrors())
+		}
+		if text != tc.Expected {
+			t.Errorf("%s: got %q, want %q", tc.File, text, tc.Expected)
+		}
+	}
+}
+
+func TestListSaveLoad(t *testing.T) {
+	story := loadStory(t, "lists/list-save-load.ink.json")
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "a, x, c\n" {
+		t.Errorf("Got %q", text)
+	}
+
+	saved, err := story.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+
+	restored := loadStory(t, "lists/list-save-load.ink.json")
+	if err := restored.LoadState(saved); err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	if err := restored.ChoosePathString("elsewhere"); err != nil {
+		t.Fatalf("ChoosePathString failed: %v", err)
+	}
+	text, err = restored.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "z\n" {
+		t.Errorf("Got %q after loading", text)
+	}
+}
+
+func TestEmptyListKeepsOriginsAcrossSaveLoad(t *testing.T) {
+	// x starts as (a), so once it is emptied it differs from its default
+	// and is written to the save along with its origins.
+	story := loadStory(t, "lists/empty-list-origin-save-load.ink.json")
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "a, b, c\n" {
+		t.Errorf("Got %q", text)
+	}
+
+	saved, err := story.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	if !strings.Contains(saved, `"origins"`) {
+		t.Errorf("Expected the empty list's origins in the save, got %s", saved)
+	}
+
+	restored := loadStory(t, "lists/empty-list-origin-save-load.ink.json")
+	if err := restored.LoadState(saved); err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	if err := restored.ChoosePathString("elsewhere"); err != nil {
+		t.Fatalf("ChoosePathString failed: %v", err)
+	}
+	text, err = restored.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "a, b, c\n" {
+		t.Errorf("Got %q after loading", text)
+	}
+}
+
+func TestUnchangedEmptyListIsNotSaved(t *testing.T) {
+	// An empty list global that still equals its empty default is skipped,
+	// like any other unchanged global.
+	story := loadStory(t, "lists/empty-list-origin-after-assignment.ink.json")
+	if _, err := story.ContinueMaximally(); err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+
+	saved, err := story.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	if !strings.Contains(saved, `"variablesState":{}`) {
+		t.Errorf("Expected no saved globals, got %s", saved)
+	}
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/samdammers/ink-go/ink"
+)
+
+// continueDiceRolls returns the non-empty lines produced by the story.
+func continueDiceRolls(t *testing.T, story *ink.Story, count int) []string {
+	t.Helper()
+	var lines []string
+	for story.CanContinue() && len(lines) < count {
+		text, err := story.Continue()
+		if err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		if strings.TrimSpace(text) != "" {
+			lines = append(lines, text)
+		}
+	}
+	return lines
+}
+
+func TestSeedRandom(t *testing.T) {
+	story := loadStory(t, "function/rnd-func.ink.json")
+
+	// Same sequence as the reference runtime for SEED_RANDOM(10)
+	expected := []string{
+		"Rolling dice 1: 6.\n",
+		"Rolling dice 2: 6.\n",
+		"Rolling dice 3: 4.\n",
+		"Rolling dice 4: 2.\n",
+	}
+	lines := continueDiceRolls(t, story, len(expected))
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %q", len(expected), lines)
+	}
+	for i, want := range expected {
+		if lines[i] != want {
+			t.Errorf("Line %d: got %q, want %q", i, lines[i], want)
+		}
+	}
+}
+
+func TestRandomSurvivesSaveLoad(t *testing.T) {
+	story := loadStory(t, "function/rnd-func.ink.json")
+	first := continueDiceRolls(t, story, 2)
+	if len(first) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", first)
+	}
+
+	saved, err := story.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	expected := continueDiceRolls(t, story, 2)
+
+	restored := loadStory(t, "function/rnd-func.ink.json")
+	if err := restored.LoadState(saved); err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	lines := continueDiceRolls(t, restored, 2)
+	if strings.Join(lines, "") != strings.Join(expected, "") {
+		t.Errorf("Got %q after loading, want %q", lines, expected)
+	}
+}
+
+// playShuffle returns the trimmed text of each pass through a shuffle fixture
+// that loops back to itself with a single "Try again" choice.
+func playShuffle(t *testing.T, file string, seed, passes int) []string {
+	t.Helper()
+	story := loadStory(t, file)
+	story.State().StorySeed = seed
+
+	var results []string
+	for i := 0; i < passes; i++ {
+		if i > 0 {
+			if err := story.ChooseChoiceIndex(0); err != nil {
+				t.Fatalf("ChooseChoiceIndex failed: %v", err)
+			}
+		}
+		text, err := story.ContinueMaximally()
+		if err != nil {
+			t.Fatalf("ContinueMaximally failed: %v", err)
+		}
+		results = append(results, strings.TrimSpace(text))
+	}
+	return results
+}
+
+func TestShuffle(t *testing.T) {
+	for seed := 0; seed < 5; seed++ {
+		results := playShuffle(t, "conditional/shuffle.ink.json", seed, 6)
+
+		// Each full loop around the shuffle shows every element exactly once
+		for loop := 0; loop < 2; loop++ {
+			pass := slices.Clone(results[loop*3 : loop*3+3])
+			slices.Sort(pass)
+			if want := []string{"2 of Diamonds.", "Ace of Hearts.", "King of Spades."}; !slices.Equal(pass, want) {
+				t.Errorf("Seed %d, loop %d: got %q", seed, loop, pass)
+			}
+		}
+
+		again := playShuffle(t, "conditional/shuffle.ink.json", seed, 6)
+		if !slices.Equal(results, again) {
+			t.Errorf("Seed %d: shuffle not deterministic, got %q then %q", seed, results, again)
+		}
+	}
+}
+
+func TestShuffleOnce(t *testing.T) {
+	results := playShuffle(t, "conditional/shuffle_once.ink.json", 3, 4)
+
+	first := slices.Clone(results[:2])
+	slices.Sort(first)
+	if want := []string{"one", "two"}; !slices.Equal(first, want) {
+		t.Errorf("Got %q for the first two passes", results[:2])
+	}
+	for _, text := range results[2:] {
+		if text != "" {
+			t.Errorf("Expected no output once the shuffle is exhausted, got %q", text)
+		}
+	}
+}
+
+func TestShuffleStopping(t *testing.T) {
+	results := playShuffle(t, "conditional/shuffle_stopping.ink.json", 3, 5)
+
+	first := slices.Clone(results[:2])
+	slices.Sort(first)
+	if want := []string{"one", "two"}; !slices.Equal(first, want) {
+		t.Errorf("Got %q for the first two passes", results[:2])
+	}
+	for _, text := range results[2:] {
+		if text != "final" {
+			t.Errorf("Expected the stopping shuffle to stop on %q, got %q", "final", text)
+		}
+	}
+}
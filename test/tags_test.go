@@ -0,0 +1,225 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/samdammers/ink-go/ink"
+)
+
+// loadStory reads a compiled fixture from testdata and creates a story from it.
+func loadStory(t *testing.T, file string) *ink.Story {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatalf("Failed to read file %s: %v", file, err)
+	}
+	story, err := ink.NewStory(string(content))
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	return story
+}
+
+func TestTags(t *testing.T) {
+	story := loadStory(t, "tags/tags.ink.json")
+
+	text, err := story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "This is the content\n" {
+		t.Errorf("Got text %q", text)
+	}
+	if want := []string{"author: Joe", "title: My Great Story"}; !slices.Equal(story.CurrentTags(), want) {
+		t.Errorf("Got tags %q, want %q", story.CurrentTags(), want)
+	}
+
+	if err := story.ChoosePathString("knot"); err != nil {
+		t.Fatalf("ChoosePathString failed: %v", err)
+	}
+	text, err = story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "Knot content\n" {
+		t.Errorf("Got text %q", text)
+	}
+	if want := []string{"knot tag"}; !slices.Equal(story.CurrentTags(), want) {
+		t.Errorf("Got tags %q, want %q", story.CurrentTags(), want)
+	}
+
+	// Tags after the last line of content belong to the next (empty) line.
+	text, err = story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "" {
+		t.Errorf("Got text %q", text)
+	}
+	if want := []string{"end of knot tag"}; !slices.Equal(story.CurrentTags(), want) {
+		t.Errorf("Got tags %q, want %q", story.CurrentTags(), want)
+	}
+}
+
+func TestTagsWithDynamicContent(t *testing.T) {
+	// tag # pic{5+3}.jpg
+	jsonStr := `{"inkVersion":21,"root":[["^tag ","#","^pic","ev",5,3,"+","out","/ev","^.jpg","/#","\n","done"],"done",null],"listDefs":{}}`
+
+	story, err := ink.NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	if _, err := story.Continue(); err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if want := []string{"pic8.jpg"}; !slices.Equal(story.CurrentTags(), want) {
+		t.Errorf("Got tags %q, want %q", story.CurrentTags(), want)
+	}
+}
+
+func TestTagsForContentAtPath(t *testing.T) {
+	story := loadStory(t, "tags/tags.ink.json")
+	before, err := story.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+
+	cases := []struct {
+		Path     string
+		Expected []string
+	}{
+		{Path: "knot", Expected: []string{"knot tag"}},
+		{Path: "knot.stitch", Expected: []string{"stitch tag"}},
+	}
+	for _, tc := range cases {
+		tags, err := story.TagsForContentAtPath(tc.Path)
+		if err != nil {
+			t.Fatalf("TagsForContentAtPath(%q) failed: %v", tc.Path, err)
+		}
+		if !slices.Equal(tags, tc.Expected) {
+			t.Errorf("TagsForContentAtPath(%q) = %q, want %q", tc.Path, tags, tc.Expected)
+		}
+	}
+
+	globalTags, err := story.GlobalTags()
+	if err != nil {
+		t.Fatalf("GlobalTags failed: %v", err)
+	}
+	if want := []string{"author: Joe", "title: My Great Story"}; !slices.Equal(globalTags, want) {
+		t.Errorf("GlobalTags() = %q, want %q", globalTags, want)
+	}
+
+	after, err := story.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	if before != after {
+		t.Errorf("Tag queries changed the story state")
+	}
+
+	if _, err := story.TagsForContentAtPath("missing_knot"); err == nil {
+		t.Errorf("Expected an error for a missing path")
+	}
+}
+
+func TestTagsAfterControlCommands(t *testing.T) {
+	// Control commands at the start of a knot, such as an empty evaluation,
+	// don't end its leading tags.
+	jsonStr := `{"inkVersion":21,"root":[["done"],"done",{"knot":["ev","/ev","#","^first","/#","ev","/ev","#","^second","/#","^Text","\n","done",null]}],"listDefs":{}}`
+
+	story, err := ink.NewStory(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	tags, err := story.TagsForContentAtPath("knot")
+	if err != nil {
+		t.Fatalf("TagsForContentAtPath failed: %v", err)
+	}
+	if want := []string{"first", "second"}; !slices.Equal(tags, want) {
+		t.Errorf("Got tags %q, want %q", tags, want)
+	}
+}
+
+func TestTagsInChoice(t *testing.T) {
+	story := loadStory(t, "tags/tagsInChoice.ink.json")
+
+	if _, err := story.Continue(); err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if len(story.CurrentTags()) != 0 {
+		t.Errorf("Expected no line tags, got %q", story.CurrentTags())
+	}
+
+	choices := story.GetCurrentChoices()
+	if len(choices) != 1 {
+		t.Fatalf("Expected 1 choice, got %d", len(choices))
+	}
+	if choices[0].Text != "one two" {
+		t.Errorf("Got choice text %q", choices[0].Text)
+	}
+	if want := []string{"one", "two"}; !slices.Equal(choices[0].Tags, want) {
+		t.Errorf("Got choice tags %q, want %q", choices[0].Tags, want)
+	}
+
+	if err := story.ChooseChoiceIndex(0); err != nil {
+		t.Fatalf("ChooseChoiceIndex failed: %v", err)
+	}
+	text, err := story.Continue()
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if text != "one three" {
+		t.Errorf("Got text %q", text)
+	}
+	if want := []string{"one", "three"}; !slices.Equal(story.CurrentTags(), want) {
+		t.Errorf("Got tags %q, want %q", story.CurrentTags(), want)
+	}
+}
+
+func TestTagsInChoiceDynamic(t *testing.T) {
+	story := loadStory(t, "tags/tagsInChoiceDynamic.ink.json")
+
+	if _, err := story.Continue(); err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+
+	expected := [][]string{
+		{"tag Name"},
+		{"tag 1 Name 2 3 4"},
+		{"Name tag 1 2 3 4"},
+	}
+	choices := story.GetCurrentChoices()
+	if len(choices) != len(expected) {
+		t.Fatalf("Expected %d choices, got %d", len(expected), len(choices))
+	}
+	for i, want := range expected {
+		if !slices.Equal(choices[i].Tags, want) {
+			t.Errorf("Choice %d: got tags %q, want %q", i, choices[i].Tags, want)
+		}
+	}
+	if choices[0].Text != "Choice" || choices[1].Text != "Choice2" {
+		t.Errorf("Got choice texts %q, %q", choices[0].Text, choices[1].Text)
+	}
+
+	// Choice tags survive a save/load round trip
+	saved, err := story.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	restored := loadStory(t, "tags/tagsInChoiceDynamic.ink.json")
+	if err := restored.LoadState(saved); err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	restoredChoices := restored.GetCurrentChoices()
+	if len(restoredChoices) != len(expected) {
+		t.Fatalf("Expected %d restored choices, got %d", len(expected), len(restoredChoices))
+	}
+	for i, want := range expected {
+		if !slices.Equal(restoredChoices[i].Tags, want) {
+			t.Errorf("Restored choice %d: got tags %q, want %q", i, restoredChoices[i].Tags, want)
+		}
+	}
+}
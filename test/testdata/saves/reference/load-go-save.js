@@ -0,0 +1,19 @@
+// Loads a save of save-compat.ink written by ToJSON into inkjs, and checks
+// that it plays on from the choice as it does in Go.
+// Usage: node load-go-save.js ../save-compat.v10.json
+const fs = require("fs");
+const path = require("path");
+const { Story } = require("inkjs");
+
+const json = fs.readFileSync(path.join(__dirname, "..", "save-compat.ink.json"), "utf8");
+const story = new Story(json);
+story.state.LoadJson(fs.readFileSync(process.argv[2], "utf8"));
+story.ChooseChoiceIndex(0);
+
+const text = story.ContinueMaximally();
+const want = "Gold 10, mood happy, name Ann.\n";
+if (text !== want) {
+  console.error(`got ${JSON.stringify(text)}, want ${JSON.stringify(want)}`);
+  process.exit(1);
+}
+console.log("ok");
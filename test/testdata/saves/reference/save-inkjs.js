@@ -0,0 +1,11 @@
+// Writes a save of save-compat.ink from inkjs, waiting at its choice.
+// Usage: node save-inkjs.js > save-compat.inkjs.json
+const fs = require("fs");
+const path = require("path");
+const { Story } = require("inkjs");
+
+const json = fs.readFileSync(path.join(__dirname, "..", "save-compat.ink.json"), "utf8");
+const story = new Story(json);
+story.ContinueMaximally();
+story.state.storySeed = 44;
+process.stdout.write(story.state.ToJson());
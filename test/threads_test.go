@@ -0,0 +1,102 @@
+package test
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestThreadChoices(t *testing.T) {
+	story := loadStory(t, "threads/thread-bug.ink.json")
+
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "Here is some gold. Do you want it?\n" {
+		t.Errorf("Got %q", text)
+	}
+
+	// "No" comes from the thread and diverts back through a temporary
+	// divert target, which only exists in the thread's call stack.
+	for i := 0; i < 2; i++ {
+		if want := []string{"No", "Yes"}; !slices.Equal(choiceTexts(story), want) {
+			t.Fatalf("Got choices %q, want %q", choiceTexts(story), want)
+		}
+		if err := story.ChooseChoiceIndex(0); err != nil {
+			t.Fatalf("ChooseChoiceIndex failed: %v", err)
+		}
+		text, err = story.ContinueMaximally()
+		if err != nil {
+			t.Fatalf("ContinueMaximally failed: %v", err)
+		}
+		if text != "No\nTry again!\n" {
+			t.Errorf("Got %q", text)
+		}
+	}
+
+	if err := story.ChooseChoiceIndex(1); err != nil {
+		t.Fatalf("ChooseChoiceIndex failed: %v", err)
+	}
+	text, err = story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "Yes\nYou win!\n" {
+		t.Errorf("Got %q", text)
+	}
+	if story.State().HasError() {
+		t.Errorf("Unexpected errors: %v", story.State().GetCurrentErrors())
+	}
+}
+
+func TestThreadChoicesSurviveSaveLoad(t *testing.T) {
+	story := loadStory(t, "threads/thread-bug.ink.json")
+	if _, err := story.ContinueMaximally(); err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+
+	saved, err := story.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	restored := loadStory(t, "threads/thread-bug.ink.json")
+	if err := restored.LoadState(saved); err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+
+	if want := []string{"No", "Yes"}; !slices.Equal(choiceTexts(restored), want) {
+		t.Fatalf("Got choices %q, want %q", choiceTexts(restored), want)
+	}
+	if err := restored.ChooseChoiceIndex(0); err != nil {
+		t.Fatalf("ChooseChoiceIndex failed: %v", err)
+	}
+	text, err := restored.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "No\nTry again!\n" {
+		t.Errorf("Got %q", text)
+	}
+	if want := []string{"No", "Yes"}; !slices.Equal(choiceTexts(restored), want) {
+		t.Errorf("Got choices %q after loading, want %q", choiceTexts(restored), want)
+	}
+	if restored.State().HasError() {
+		t.Errorf("Unexpected errors: %v", restored.State().GetCurrentErrors())
+	}
+}
+
+func TestThreadInTunnel(t *testing.T) {
+	// The thread's copy of the call stack includes the tunnel, which must
+	// be left alone when the thread runs out of content.
+	story := loadStory(t, "threads/thread-in-tunnel.ink.json")
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if text != "Thread text.\nIn tunnel.\nAfter tunnel.\n" {
+		t.Errorf("Got %q", text)
+	}
+	if story.State().HasError() {
+		t.Errorf("Unexpected errors: %v", story.State().GetCurrentErrors())
+	}
+}
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/samdammers/ink-go/ink"
+)
+
+func TestVariableObservers(t *testing.T) {
+	story := loadStory(t, "runtime/variable-observers.ink.json")
+
+	currentValue := 0
+	callCount := 0
+	err := story.ObserveVariable("x", func(name string, value any) {
+		if name != "x" {
+			t.Errorf("Observer called for %q", name)
+		}
+		currentValue = value.(int)
+		callCount++
+	})
+	if err != nil {
+		t.Fatalf("ObserveVariable failed: %v", err)
+	}
+
+	if _, err := story.ContinueMaximally(); err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if currentValue != 5 || callCount != 1 {
+		t.Errorf("Got x = %d after %d calls, want 5 after 1", currentValue, callCount)
+	}
+
+	if err := story.ChooseChoiceIndex(0); err != nil {
+		t.Fatalf("ChooseChoiceIndex failed: %v", err)
+	}
+	if _, err := story.ContinueMaximally(); err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if currentValue != 10 || callCount != 2 {
+		t.Errorf("Got x = %d after %d calls, want 10 after 2", currentValue, callCount)
+	}
+
+	// Outside Continue, a change is reported straight away.
+	story.State().GetVariablesState().SetGlobal("x", ink.NewIntValue(15))
+	if currentValue != 15 || callCount != 3 {
+		t.Errorf("Got x = %d after %d calls, want 15 after 3", currentValue, callCount)
+	}
+
+	story.RemoveVariableObserver("x")
+	story.State().GetVariablesState().SetGlobal("x", ink.NewIntValue(20))
+	if callCount != 3 {
+		t.Errorf("Observer called after being removed")
+	}
+}
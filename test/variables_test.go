@@ -0,0 +1,76 @@
+package test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/samdammers/ink-go/ink"
+)
+
+func TestSetVariableFromCode(t *testing.T) {
+	story := loadStory(t, "runtime/set-get-variables.ink.json")
+	if _, err := story.ContinueMaximally(); err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+
+	vars := story.Variables()
+	if x, err := ink.GetAs[int](vars, "x"); err != nil || x != 10 {
+		t.Fatalf("GetAs[int](x) = %v, %v", x, err)
+	}
+	if err := vars.Set("x", 15); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	if err := story.ChooseChoiceIndex(0); err != nil {
+		t.Fatalf("ChooseChoiceIndex failed: %v", err)
+	}
+	text, err := story.ContinueMaximally()
+	if err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+	if strings.TrimSpace(text) != "OK" {
+		t.Errorf("Got %q", text)
+	}
+}
+
+func TestStringVariableFixtures(t *testing.T) {
+	cases := []struct {
+		File     string
+		Expected string
+	}{
+		{File: "variable/varcalc.ink.json", Expected: "The values are true and -1 and -6 and aa.\n"},
+		{File: "variable/variable-declaration.ink.json", Expected: "\"My name is Jean Passepartout, but my friend's call me Jackie. I'm 23 years old.\"\n"},
+	}
+	for _, tc := range cases {
+		story := loadStory(t, tc.File)
+		text, err := story.ContinueMaximally()
+		if err != nil {
+			t.Fatalf("%s: ContinueMaximally failed: %v", tc.File, err)
+		}
+		if text != tc.Expected {
+			t.Errorf("%s: got %q, want %q", tc.File, text, tc.Expected)
+		}
+	}
+}
+
+func TestSetStringVariableBetweenLines(t *testing.T) {
+	story := loadStory(t, "misc/issue15.ink.json")
+
+	var lines []string
+	for story.CanContinue() {
+		line, err := story.Continue()
+		if err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		lines = append(lines, line)
+		if strings.HasPrefix(line, "SET_X:") {
+			if err := story.Variables().Set("x", "done"); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+		}
+	}
+	if want := []string{"This is a test\n", "SET_X:\n", "X is set\n"}; !slices.Equal(lines, want) {
+		t.Errorf("Got lines %q, want %q", lines, want)
+	}
+}
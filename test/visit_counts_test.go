@@ -0,0 +1,24 @@
+package test
+
+import "testing"
+
+func TestReadVisitCounts(t *testing.T) {
+	story := loadStory(t, "runtime/read-visit-counts.ink.json")
+
+	if _, err := story.ContinueMaximally(); err != nil {
+		t.Fatalf("ContinueMaximally failed: %v", err)
+	}
+
+	cases := []struct {
+		Path     string
+		Expected int
+	}{
+		{Path: "two.s2", Expected: 4},
+		{Path: "two", Expected: 5},
+	}
+	for _, tc := range cases {
+		if count := story.State().VisitCountAtPathString(tc.Path); count != tc.Expected {
+			t.Errorf("VisitCountAtPathString(%q) = %d, want %d", tc.Path, count, tc.Expected)
+		}
+	}
+}